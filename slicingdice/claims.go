@@ -0,0 +1,92 @@
+package slicingdice
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// KeyClaims holds the claims carried by a SlicingDice API key. Keys are
+// JWTs whose payload names the permission level of the key and the project
+// and client it belongs to.
+type KeyClaims struct {
+	PermissionLevel int                    // 1 for read keys, 2 for write keys, 3 for master keys
+	ProjectID       int                    // ID of the project the key gives access to
+	ClientID        int                    // ID of the client owning the project
+	Raw             map[string]interface{} // every claim of the key
+}
+
+// ParseKeyClaims decodes the claims of key. The signature is not verified,
+// so the claims tell what the API would allow the key to do, not whether
+// the key is genuine. Keys that are not JWTs return an error.
+func ParseKeyClaims(key string) (*KeyClaims, error) {
+	parts := strings.Split(key, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("API key: the key is not a JWT")
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return nil, fmt.Errorf("API key: decoding claims: %w", err)
+	}
+	var fields struct {
+		PermissionLevel int `json:"permission_level"`
+		ProjectID       int `json:"project_id"`
+		ClientID        int `json:"client_id"`
+	}
+	if err := json.Unmarshal(payload, &fields); err != nil {
+		return nil, fmt.Errorf("API key: decoding claims: %w", err)
+	}
+	claims := &KeyClaims{
+		PermissionLevel: fields.PermissionLevel,
+		ProjectID:       fields.ProjectID,
+		ClientID:        fields.ClientID,
+	}
+	if err := json.Unmarshal(payload, &claims.Raw); err != nil {
+		return nil, fmt.Errorf("API key: decoding claims: %w", err)
+	}
+	return claims, nil
+}
+
+// KeyLevel returns the key level granted by the claims, e.g. READ_KEY_LEVEL,
+// and false if the permission level is unknown.
+func (k *KeyClaims) KeyLevel() (int, bool) {
+	switch k.PermissionLevel {
+	case 1:
+		return READ_KEY_LEVEL, true
+	case 2:
+		return WRITE_KEY_LEVEL, true
+	case 3:
+		return MASTER_KEY_LEVEL, true
+	}
+	return 0, false
+}
+
+// claimedKeyLevels caches the key level granted by the claims of each key
+// seen, -1 for keys without claims the client understands, so keys are
+// only decoded once rather than on every call.
+var claimedKeyLevels sync.Map
+
+// grantedKeyLevel returns the key level granted by key according to its
+// claims, or fallback if key has no claims the client understands.
+func grantedKeyLevel(key string, fallback int) int {
+	if level, ok := claimedKeyLevels.Load(key); ok {
+		if level.(int) < 0 {
+			return fallback
+		}
+		return level.(int)
+	}
+	level := -1
+	if claims, err := ParseKeyClaims(key); err == nil {
+		if granted, ok := claims.KeyLevel(); ok {
+			level = granted
+		}
+	}
+	claimedKeyLevels.Store(key, level)
+	if level < 0 {
+		return fallback
+	}
+	return level
+}
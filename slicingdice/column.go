@@ -0,0 +1,150 @@
+package slicingdice
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// ColumnType is the type of a column.
+type ColumnType string
+
+// All the column types available in SlicingDice API.
+const (
+	TYPE_UNIQUE_ID     ColumnType = "unique-id"
+	TYPE_BOOLEAN       ColumnType = "boolean"
+	TYPE_STRING        ColumnType = "string"
+	TYPE_INTEGER       ColumnType = "integer"
+	TYPE_DECIMAL       ColumnType = "decimal"
+	TYPE_ENUMERATED    ColumnType = "enumerated"
+	TYPE_DATE          ColumnType = "date"
+	TYPE_INTEGER_EVENT ColumnType = "integer-event"
+	TYPE_DECIMAL_EVENT ColumnType = "decimal-event"
+	TYPE_STRING_EVENT  ColumnType = "string-event"
+	TYPE_DATETIME      ColumnType = "datetime"
+)
+
+// ColumnTypes lists every column type, in the order of the constants.
+var ColumnTypes = []ColumnType{
+	TYPE_UNIQUE_ID, TYPE_BOOLEAN, TYPE_STRING, TYPE_INTEGER, TYPE_DECIMAL,
+	TYPE_ENUMERATED, TYPE_DATE, TYPE_INTEGER_EVENT,
+	TYPE_DECIMAL_EVENT, TYPE_STRING_EVENT, TYPE_DATETIME,
+}
+
+// Valid reports whether t is one of ColumnTypes.
+func (t ColumnType) Valid() bool {
+	for _, valid := range ColumnTypes {
+		if t == valid {
+			return true
+		}
+	}
+	return false
+}
+
+// Decimal reports whether columns of type t accept a decimal place.
+func (t ColumnType) Decimal() bool {
+	return t == TYPE_DECIMAL || t == TYPE_DECIMAL_EVENT
+}
+
+// Cardinality is the expected number of distinct values of a string column.
+type Cardinality string
+
+// All the cardinalities of string columns.
+const (
+	CARDINALITY_HIGH Cardinality = "high"
+	CARDINALITY_LOW  Cardinality = "low"
+)
+
+// Cardinalities lists every cardinality.
+var Cardinalities = []Cardinality{CARDINALITY_HIGH, CARDINALITY_LOW}
+
+// Valid reports whether c is one of Cardinalities.
+func (c Cardinality) Valid() bool {
+	for _, valid := range Cardinalities {
+		if c == valid {
+			return true
+		}
+	}
+	return false
+}
+
+// Storage is how the values inserted in a column are kept.
+type Storage string
+
+// All the storages of columns.
+const (
+	STORAGE_LATEST_VALUE            Storage = "latest-value"
+	STORAGE_LIST_OF_VALUES          Storage = "list-of-values"
+	STORAGE_LIST_OF_DISTINCT_VALUES Storage = "list-of-distinct-values"
+)
+
+// Storages lists every storage.
+var Storages = []Storage{STORAGE_LATEST_VALUE, STORAGE_LIST_OF_VALUES, STORAGE_LIST_OF_DISTINCT_VALUES}
+
+// Valid reports whether s is one of Storages.
+func (s Storage) Valid() bool {
+	for _, valid := range Storages {
+		if s == valid {
+			return true
+		}
+	}
+	return false
+}
+
+// DecimalPlace is the number of decimal places kept by a decimal column,
+// from 0 to 5.
+type DecimalPlace int
+
+// Places returns a pointer to n decimal places, as set in Column.
+func Places(n int) *DecimalPlace {
+	places := DecimalPlace(n)
+	return &places
+}
+
+// EnumeratedRange is the list of the distinct values accepted by an
+// enumerated column.
+type EnumeratedRange []string
+
+// Column describes a column of a database. It is created by passing it to
+// CreateColumn, alone or in a slice, and listed by GetColumns through
+// ToColumnList.
+type Column struct {
+	Name         string          `json:"name"`
+	APIName      string          `json:"api-name,omitempty"`
+	Description  string          `json:"description,omitempty"`
+	Type         ColumnType      `json:"type"`
+	Category     string          `json:"category,omitempty"`
+	Cardinality  Cardinality     `json:"cardinality,omitempty"`
+	Storage      Storage         `json:"storage,omitempty"`
+	Dimension    string          `json:"dimension,omitempty"`
+	DecimalPlace *DecimalPlace   `json:"decimal-place,omitempty"` // nil for the API default
+	Range        EnumeratedRange `json:"range,omitempty"`         // values of an enumerated column
+}
+
+// Validate checks c as CreateColumn does before sending it.
+func (c Column) Validate() error {
+	query, err := columnQuery(c)
+	if err != nil {
+		return err
+	}
+	return hasValidColumn(query)
+}
+
+// columnQuery returns query as sent to CreateColumn: Column values, alone
+// or in a slice, are converted to the JSON objects they stand for, other
+// queries are returned as is.
+func columnQuery(query interface{}) (interface{}, error) {
+	switch query.(type) {
+	case Column, *Column, []Column, []*Column:
+	default:
+		return query, nil
+	}
+	data, err := json.Marshal(query)
+	if err != nil {
+		return nil, fmt.Errorf("column: %w", err)
+	}
+	var converted interface{}
+	if err := json.Unmarshal(data, &converted); err != nil {
+		return nil, fmt.Errorf("column: %w", err)
+	}
+	return converted, nil
+}
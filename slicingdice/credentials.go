@@ -0,0 +1,132 @@
+package slicingdice
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// CredentialProvider supplies the keys used to authenticate requests. The
+// client asks for them before every call, so a provider can rotate keys
+// while the client is in use. Credentials may be called concurrently and
+// must not change the APIKey it returned before.
+type CredentialProvider interface {
+	Credentials(ctx context.Context) (*APIKey, error)
+}
+
+// WithCredentialProvider sets the provider of the keys used to authenticate
+// requests. It replaces the keys set by WithAPIKey.
+func WithCredentialProvider(provider CredentialProvider) Option {
+	return func(s *SlicingDice) {
+		s.credentials = provider
+	}
+}
+
+// staticCredentials always returns the same keys.
+type staticCredentials struct {
+	key APIKey
+}
+
+// StaticCredentials returns a provider of a copy of key, which never
+// changes. It is the provider set by WithAPIKey.
+func StaticCredentials(key *APIKey) CredentialProvider {
+	p := new(staticCredentials)
+	if key != nil {
+		p.key = *key
+	}
+	return p
+}
+
+func (p *staticCredentials) Credentials(ctx context.Context) (*APIKey, error) {
+	key := p.key
+	return &key, nil
+}
+
+// envCredentials reads the keys from the environment.
+type envCredentials struct{}
+
+// EnvCredentials returns a provider reading the keys from the SD_READ_KEY,
+// SD_WRITE_KEY, SD_MASTER_KEY and SD_CUSTOM_KEY environment variables
+// before every call.
+func EnvCredentials() CredentialProvider {
+	return envCredentials{}
+}
+
+func (envCredentials) Credentials(ctx context.Context) (*APIKey, error) {
+	return &APIKey{
+		ReadKey:   os.Getenv("SD_READ_KEY"),
+		WriteKey:  os.Getenv("SD_WRITE_KEY"),
+		MasterKey: os.Getenv("SD_MASTER_KEY"),
+		CustomKey: os.Getenv("SD_CUSTOM_KEY"),
+	}, nil
+}
+
+// fileCredentials reads the keys from a JSON file, again whenever the file
+// changes.
+type fileCredentials struct {
+	path string
+
+	mu      sync.Mutex
+	key     *APIKey
+	modTime time.Time
+	size    int64
+}
+
+// FileCredentials returns a provider reading the keys from the JSON file
+// at path, e.g.
+//
+//	{"read-key": "...", "write-key": "...", "master-key": "...", "custom-key": "..."}
+//
+// The file is read again when its modification time or size changes. If it
+// cannot be read or parsed after it was read once, the previous keys are
+// kept until it can. Replace the file with a rename to rotate keys without
+// readers seeing a partial write.
+func FileCredentials(path string) CredentialProvider {
+	return &fileCredentials{path: path}
+}
+
+func (p *fileCredentials) Credentials(ctx context.Context) (*APIKey, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	info, err := os.Stat(p.path)
+	if err == nil && p.key != nil && info.ModTime().Equal(p.modTime) && info.Size() == p.size {
+		return p.key, nil
+	}
+	if err == nil {
+		var key *APIKey
+		if key, err = readCredentialsFile(p.path); err == nil {
+			p.key, p.modTime, p.size = key, info.ModTime(), info.Size()
+			return p.key, nil
+		}
+	}
+	if p.key != nil {
+		return p.key, nil
+	}
+	return nil, fmt.Errorf("credentials: %w", err)
+}
+
+// readCredentialsFile parses the keys in the JSON file at path.
+func readCredentialsFile(path string) (*APIKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var fields struct {
+		ReadKey   string `json:"read-key"`
+		WriteKey  string `json:"write-key"`
+		MasterKey string `json:"master-key"`
+		CustomKey string `json:"custom-key"`
+	}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	return &APIKey{
+		ReadKey:   fields.ReadKey,
+		WriteKey:  fields.WriteKey,
+		MasterKey: fields.MasterKey,
+		CustomKey: fields.CustomKey,
+	}, nil
+}
@@ -0,0 +1,110 @@
+package slicingdice
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// writeKeys writes the keys file at path with the given modification time.
+func writeKeys(t *testing.T, path string, content string, modTime time.Time) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFileCredentials(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keys.json")
+	start := time.Now().Add(-time.Hour)
+	steps := []struct {
+		name   string
+		change func()
+		want   string
+	}{
+		{"first read", func() { writeKeys(t, path, `{"master-key": "one"}`, start) }, "one"},
+		{"unchanged file", func() { writeKeys(t, path, `{"master-key": "two"}`, start) }, "one"},
+		{"size change", func() { writeKeys(t, path, `{"master-key": "three"}`, start) }, "three"},
+		{"modification time change", func() { writeKeys(t, path, `{"master-key": "four!"}`, start.Add(time.Second)) }, "four!"},
+		{"unparsable file", func() { writeKeys(t, path, `{"master-key": `, start.Add(2*time.Second)) }, "four!"},
+		{"removed file", func() { os.Remove(path) }, "four!"},
+		{"file back", func() { writeKeys(t, path, `{"read-key": "r", "master-key": "five"}`, start.Add(3*time.Second)) }, "five"},
+	}
+	provider := FileCredentials(path)
+	for _, step := range steps {
+		step.change()
+		key, err := provider.Credentials(context.Background())
+		if err != nil || key.MasterKey != step.want {
+			t.Fatalf("%s: Credentials() = %+v, %v, want master key %q", step.name, key, err, step.want)
+		}
+	}
+
+	if _, err := FileCredentials(filepath.Join(t.TempDir(), "missing.json")).Credentials(context.Background()); err == nil {
+		t.Error("Credentials() of a missing file succeeded, want an error")
+	}
+	unparsable := filepath.Join(t.TempDir(), "keys.json")
+	writeKeys(t, unparsable, `{`, start)
+	if _, err := FileCredentials(unparsable).Credentials(context.Background()); err == nil {
+		t.Error("Credentials() of an unparsable file succeeded, want an error")
+	}
+}
+
+func TestFileCredentialsRotation(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "keys.json")
+	start := time.Now().Add(-time.Hour)
+	writeKeys(t, path, `{"master-key": "key-0"}`, start)
+	provider := FileCredentials(path)
+
+	const rotations = 20
+	valid := make(map[string]bool, rotations+1)
+	for i := 0; i <= rotations; i++ {
+		valid[fmt.Sprintf("key-%d", i)] = true
+	}
+	done := make(chan struct{})
+	errs := make(chan error, 8)
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				key, err := provider.Credentials(context.Background())
+				if err != nil || !valid[key.MasterKey] {
+					errs <- fmt.Errorf("Credentials() = %+v, %v during rotation", key, err)
+					return
+				}
+			}
+		}()
+	}
+	for i := 1; i <= rotations; i++ {
+		// Rotate with a rename, so readers never see a partial write.
+		tmp := filepath.Join(dir, "keys.json.tmp")
+		writeKeys(t, tmp, fmt.Sprintf(`{"master-key": "key-%d"}`, i), start.Add(time.Duration(i)*time.Second))
+		if err := os.Rename(tmp, path); err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(time.Millisecond)
+	}
+	close(done)
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+	if key, err := provider.Credentials(context.Background()); err != nil || key.MasterKey != fmt.Sprintf("key-%d", rotations) {
+		t.Errorf("Credentials() after rotation = %+v, %v, want the last key", key, err)
+	}
+}
@@ -0,0 +1,89 @@
+package slicingdice
+
+import (
+	"bytes"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+)
+
+func TestDryRun(t *testing.T) {
+	var connections int32
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"status":"success"}`))
+	}))
+	server.Config.ConnState = func(conn net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			atomic.AddInt32(&connections, 1)
+		}
+	}
+	server.Start()
+	defer server.Close()
+
+	var out bytes.Buffer
+	log := NewDryRunLog(&out)
+	s := NewClient(WithBaseURL(server.URL), WithDryRun(log))
+	count := map[string]interface{}{"query-name": "q", "query": []interface{}{map[string]interface{}{"car-model": map[string]interface{}{"equals": "ford ka"}}}}
+	calls := []func() (map[string]interface{}, error){
+		func() (map[string]interface{}, error) { return s.CountEntity(count) },
+		func() (map[string]interface{}, error) {
+			return s.Insert(map[string]interface{}{"u1": map[string]interface{}{"car-model": "ford ka"}})
+		},
+		func() (map[string]interface{}, error) { return s.Sql("SELECT COUNT(*) FROM users") },
+		func() (map[string]interface{}, error) { return s.DeleteSavedQuery("q") },
+	}
+	for i, call := range calls {
+		result, err := call()
+		if err != nil || result["status"] != "success" || result["dry-run"] != true {
+			t.Fatalf("call %d = %v, %v, want a dry-run success", i, result, err)
+		}
+	}
+	if _, err := s.CountEntity([]interface{}{1}); err == nil {
+		t.Error("invalid query recorded, want a validation error")
+	}
+	if n := atomic.LoadInt32(&connections); n != 0 {
+		t.Errorf("server got %d connections, want none", n)
+	}
+
+	want := []DryRunEntry{
+		{COUNT_ENTITY, "POST", READ_KEY_LEVEL, server.URL + COUNT_ENTITY, "application/json",
+			[]byte(`{"query":[{"car-model":{"equals":"ford ka"}}],"query-name":"q"}` + "\n")},
+		{INSERT, "POST", WRITE_KEY_LEVEL, server.URL + INSERT, "application/json",
+			[]byte(`{"u1":{"car-model":"ford ka"}}` + "\n")},
+		{SQL, "POST", READ_KEY_LEVEL, server.URL + SQL, "application/sql",
+			[]byte("SELECT COUNT(*) FROM users")},
+		{SAVED, "DELETE", MASTER_KEY_LEVEL, server.URL + SAVED + "q", "application/json",
+			[]byte("null\n")},
+	}
+	entries := log.Entries()
+	if len(entries) != len(want) {
+		t.Fatalf("got %d entries, want %d", len(entries), len(want))
+	}
+	for i, entry := range entries {
+		if entry.Endpoint != want[i].Endpoint || entry.Method != want[i].Method || entry.KeyLevel != want[i].KeyLevel ||
+			entry.URL != want[i].URL || entry.ContentType != want[i].ContentType || !bytes.Equal(entry.Body, want[i].Body) {
+			t.Errorf("entry %d = %+v, want %+v", i, entry, want[i])
+		}
+	}
+
+	lines := bytes.Split(bytes.TrimSuffix(out.Bytes(), []byte("\n")), []byte("\n"))
+	if len(lines) != len(want) {
+		t.Fatalf("got %d lines, want %d:\n%s", len(lines), len(want), out.Bytes())
+	}
+	var first struct {
+		Endpoint string                 `json:"endpoint"`
+		KeyLevel int                    `json:"key_level"`
+		Body     map[string]interface{} `json:"body"`
+	}
+	if err := json.Unmarshal(lines[0], &first); err != nil || first.Endpoint != COUNT_ENTITY || first.KeyLevel != READ_KEY_LEVEL || first.Body["query-name"] != "q" {
+		t.Errorf("line %s decoded as %+v, %v", lines[0], first, err)
+	}
+
+	log.Reset()
+	if entries := log.Entries(); len(entries) != 0 {
+		t.Errorf("got %d entries after Reset, want none", len(entries))
+	}
+}
@@ -0,0 +1,86 @@
+package slicingdice
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// httpDump writes the requests of a client as curl commands, followed by
+// their responses.
+type httpDump struct {
+	mu      sync.Mutex
+	w       io.Writer
+	showKey bool
+}
+
+// WithHTTPDump writes every request sent by the client to w as a
+// ready-to-run curl command, followed by the status, headers and body of its
+// response, or the error that prevented it. Retries are written as separate
+// requests. The key is replaced by $SD_API_KEY, to be set in the shell,
+// unless showKey is true. Writes are serialized, so w may be shared by
+// concurrent calls.
+func WithHTTPDump(w io.Writer, showKey bool) Option {
+	return func(s *SlicingDice) {
+		s.dump = &httpDump{w: w, showKey: showKey}
+	}
+}
+
+// write writes request, whose body is body, and its response, whose body
+// is resBody, or err if there is no response.
+func (d *httpDump) write(request *http.Request, body []byte, res *http.Response, resBody []byte, err error) {
+	var b strings.Builder
+	fmt.Fprintf(&b, "curl -X %s %s", request.Method, shellQuote(request.URL.String()))
+	for _, name := range sortedHeaderNames(request.Header) {
+		for _, value := range request.Header[name] {
+			if name == "Authorization" && !d.showKey {
+				fmt.Fprintf(&b, " \\\n  -H \"%s: $SD_API_KEY\"", name)
+				continue
+			}
+			fmt.Fprintf(&b, " \\\n  -H %s", shellQuote(name+": "+value))
+		}
+	}
+	if len(body) != 0 {
+		fmt.Fprintf(&b, " \\\n  --data-binary %s", shellQuote(strings.TrimSuffix(string(body), "\n")))
+	}
+	b.WriteString("\n")
+	if res == nil {
+		fmt.Fprintf(&b, "# error: %v\n\n", err)
+	} else {
+		fmt.Fprintf(&b, "< %s %s\n", res.Proto, res.Status)
+		for _, name := range sortedHeaderNames(res.Header) {
+			for _, value := range res.Header[name] {
+				fmt.Fprintf(&b, "< %s: %s\n", name, value)
+			}
+		}
+		fmt.Fprintf(&b, "<\n%s\n\n", bytes.TrimSuffix(resBody, []byte("\n")))
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	io.WriteString(d.w, b.String())
+}
+
+// sortedHeaderNames returns the names of header in order.
+func sortedHeaderNames(header http.Header) []string {
+	names := make([]string, 0, len(header))
+	for name := range header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// shellQuote quotes s as a single shell word.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// teeBody copies a response body to a buffer as it is read.
+type teeBody struct {
+	io.Reader
+	io.Closer
+}
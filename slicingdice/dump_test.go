@@ -0,0 +1,80 @@
+package slicingdice
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// dumpedRequest is a request received by the test server.
+type dumpedRequest struct {
+	method, path, authorization, contentType, body string
+}
+
+func TestHTTPDump(t *testing.T) {
+	var mu sync.Mutex
+	var received []dumpedRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		received = append(received, dumpedRequest{r.Method, r.URL.Path, r.Header.Get("Authorization"), r.Header.Get("Content-Type"), string(body)})
+		mu.Unlock()
+		w.Header().Set("X-Took", "1")
+		w.Write([]byte(`{"status":"success","result":{"a":1}}`))
+	}))
+	defer server.Close()
+
+	var out bytes.Buffer
+	s := NewClient(WithBaseURL(server.URL), WithAPIKey(&APIKey{MasterKey: "secret"}), WithHTTPDump(&out, false))
+	if _, err := s.Sql("SELECT * FROM users WHERE name = 'o''neil'"); err != nil {
+		t.Fatal(err)
+	}
+	dump := out.String()
+	if strings.Contains(dump, "secret") || !strings.Contains(dump, `-H "Authorization: $SD_API_KEY"`) {
+		t.Errorf("key not masked:\n%s", dump)
+	}
+	for _, want := range []string{"curl -X POST '" + server.URL + SQL + "'", "< HTTP/1.1 200 OK", "< X-Took: 1", `{"status":"success","result":{"a":1}}`} {
+		if !strings.Contains(dump, want) {
+			t.Errorf("dump lacks %q:\n%s", want, dump)
+		}
+	}
+
+	// The curl command ends at the first line not continued by a backslash.
+	lines := strings.Split(dump, "\n")
+	end := 0
+	for end < len(lines) && strings.HasSuffix(lines[end], "\\") {
+		end++
+	}
+	command := strings.Join(lines[:end+1], "\n")
+	if _, err := exec.LookPath("curl"); err != nil {
+		t.Skip("curl is not installed")
+	}
+	curl := exec.Command("sh", "-c", command+" --silent --show-error")
+	curl.Env = append(os.Environ(), "SD_API_KEY=secret")
+	if output, err := curl.CombinedOutput(); err != nil {
+		t.Fatalf("running %s: %v: %s", command, err, output)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(received) != 2 || received[1] != received[0] {
+		t.Errorf("curl sent %+v, want the request of the client %+v", received[1:], received[0])
+	}
+}
+
+func TestHTTPDumpShowKey(t *testing.T) {
+	var out bytes.Buffer
+	s := NewClient(WithBaseURL("http://127.0.0.1:1"), WithAPIKey(&APIKey{MasterKey: "secret"}), WithHTTPDump(&out, true))
+	if _, err := s.GetDatabase(); err == nil {
+		t.Fatal("GetDatabase() succeeded without a server")
+	}
+	dump := out.String()
+	if !strings.Contains(dump, "-H 'Authorization: secret'") || !strings.Contains(dump, "# error: ") {
+		t.Errorf("dump of a failed request with the key shown:\n%s", dump)
+	}
+}
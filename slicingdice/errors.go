@@ -0,0 +1,159 @@
+package slicingdice
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// Sentinel errors for the common classes of failures. Use errors.Is to check
+// whether an error returned by a client method belongs to one of them:
+//
+//	if errors.Is(err, slicingdice.ErrRateLimit) {
+//		// slow down
+//	}
+var (
+	// ErrAuth matches API errors caused by a missing, invalid or
+	// insufficient API key.
+	ErrAuth = errors.New("slicingdice: authentication failed")
+	// ErrRateLimit matches API errors returned when too many requests were
+	// made.
+	ErrRateLimit = errors.New("slicingdice: rate limit exceeded")
+	// ErrInvalidQuery matches API errors caused by a malformed request.
+	ErrInvalidQuery = errors.New("slicingdice: invalid query")
+	// ErrUnknownColumn matches API errors caused by a reference to a column
+	// that does not exist. These errors also match ErrInvalidQuery.
+	ErrUnknownColumn = errors.New("slicingdice: unknown column")
+	// ErrTimeout matches API errors reporting a timeout and requests that
+	// were aborted because their deadline or the client timeout was reached.
+	ErrTimeout = errors.New("slicingdice: timeout")
+	// ErrMissingKey matches errors returned, before anything is sent, when
+	// the client has no API key allowed to perform the call.
+	ErrMissingKey = errors.New("slicingdice: missing API key")
+	// ErrTestMode matches errors returned, before anything is sent, for
+	// destructive calls made in test mode without allowing them.
+	ErrTestMode = errors.New("slicingdice: call refused in test mode")
+)
+
+// unknownColumnMessage matches the messages of errors caused by a reference
+// to a column that does not exist.
+var unknownColumnMessage = regexp.MustCompile(`(?i)(unknown|invalid|inexistent) column|column.*(does not exist|doesn't exist|not found)`)
+
+// SDError is an error returned by the SlicingDice API.
+type SDError struct {
+	Message    string      // error message
+	MoreInfo   interface{} // the "more-info" field of the error, if any
+	StatusCode int         // HTTP status of the response
+	Code       int         // API error code, zero if the API sent none
+}
+
+// newSDError builds an SDError from an entry of the "errors" array of a
+// response.
+func newSDError(entry map[string]interface{}, statusCode int) *SDError {
+	e := &SDError{StatusCode: statusCode}
+	e.Message, _ = entry["message"].(string)
+	e.MoreInfo = entry["more-info"]
+	if code, ok := entry["code"].(float64); ok {
+		e.Code = int(code)
+	}
+	return e
+}
+
+// newAPIError builds the error reported by the "errors" array of a
+// response: an SDError if it has a single entry, a MultiError if it has
+// several, and nil if it is empty.
+func newAPIError(errorsField interface{}, statusCode int) error {
+	entries, ok := errorsField.([]interface{})
+	if !ok {
+		entries = []interface{}{errorsField}
+	}
+	errs := make([]*SDError, 0, len(entries))
+	for _, entry := range entries {
+		if fields, ok := entry.(map[string]interface{}); ok {
+			errs = append(errs, newSDError(fields, statusCode))
+		} else {
+			errs = append(errs, &SDError{Message: fmt.Sprint(entry), StatusCode: statusCode})
+		}
+	}
+	switch len(errs) {
+	case 0:
+		return nil
+	case 1:
+		return errs[0]
+	}
+	return &MultiError{Errors: errs}
+}
+
+func (e *SDError) Error() string {
+	return fmt.Sprintf("Error Code: %d, HTTP Status: %d, Message: %s, More Info: %v", e.Code, e.StatusCode, e.Message, e.MoreInfo)
+}
+
+// HTTPStatus returns the HTTP status of the response.
+func (e *SDError) HTTPStatus() int {
+	return e.StatusCode
+}
+
+// APICode returns the API error code, zero if the API sent none.
+func (e *SDError) APICode() int {
+	return e.Code
+}
+
+// Info returns the "more-info" field of the error, nil if the API sent none.
+func (e *SDError) Info() interface{} {
+	return e.MoreInfo
+}
+
+// Is reports whether e belongs to the class of errors of target, one of the
+// sentinel errors of this package.
+func (e *SDError) Is(target error) bool {
+	switch target {
+	case ErrAuth:
+		return e.StatusCode == http.StatusUnauthorized || e.StatusCode == http.StatusForbidden
+	case ErrRateLimit:
+		return e.StatusCode == http.StatusTooManyRequests
+	case ErrInvalidQuery:
+		return e.StatusCode == http.StatusBadRequest || e.StatusCode == http.StatusUnprocessableEntity ||
+			e.Is(ErrUnknownColumn)
+	case ErrUnknownColumn:
+		return e.StatusCode >= 400 && e.StatusCode < 500 && unknownColumnMessage.MatchString(e.Message)
+	case ErrTimeout:
+		return e.StatusCode == http.StatusRequestTimeout || e.StatusCode == http.StatusGatewayTimeout
+	}
+	return false
+}
+
+// contextError annotates the error of a done context with the request it
+// interrupted. Deadlines also match ErrTimeout.
+func contextError(method string, url string, err error) error {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return fmt.Errorf("request: %s %s: %w (%w)", method, url, err, ErrTimeout)
+	}
+	return fmt.Errorf("request: %s %s: %w", method, url, err)
+}
+
+// MultiError is returned when the API reports several errors for a single
+// request, e.g. one for each invalid column of an insert. Each entry can be
+// inspected with errors.As and errors.Is, which match if any entry does.
+type MultiError struct {
+	Errors []*SDError
+}
+
+func (e *MultiError) Error() string {
+	messages := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		messages[i] = err.Error()
+	}
+	return fmt.Sprintf("%d errors: %s", len(e.Errors), strings.Join(messages, "; "))
+}
+
+// Unwrap returns every entry of the error.
+func (e *MultiError) Unwrap() []error {
+	errs := make([]error, len(e.Errors))
+	for i, err := range e.Errors {
+		errs[i] = err
+	}
+	return errs
+}
@@ -0,0 +1,163 @@
+package slicingdice
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+// Values of the "auto-create" field of an insert, set with AutoCreate.
+const (
+	AUTO_CREATE_DIMENSION = "dimension"
+	AUTO_CREATE_COLUMN    = "column"
+)
+
+// Event is a value of an event column and the time it happened at.
+type Event[T any] struct {
+	Value T
+	Date  time.Time
+}
+
+// MarshalJSON writes e as {"value": ..., "date": ...}, the date in RFC 3339
+// format, in UTC.
+func (e Event[T]) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[string]interface{}{
+		"value": insertValue(reflect.ValueOf(e.Value)),
+		"date":  e.Date.UTC().Format(time.RFC3339),
+	})
+}
+
+// InsertOption sets a field of the insert built by MarshalInsert.
+type InsertOption func(map[string]interface{})
+
+// AutoCreate asks the API to create the dimensions or columns, or both,
+// that do not exist yet, e.g. AutoCreate(AUTO_CREATE_DIMENSION,
+// AUTO_CREATE_COLUMN).
+func AutoCreate(kinds ...string) InsertOption {
+	return func(insert map[string]interface{}) {
+		insert["auto-create"] = kinds
+	}
+}
+
+// MarshalInsert builds the payload of Insert from entities, structs or
+// pointers to structs whose fields are tagged with the API name of the
+// column they hold:
+//
+//	type Car struct {
+//		ID         string                      `sd:"entity-id"`
+//		Dimension  string                      `sd:"dimension,omitempty"`
+//		Model      string                      `sd:"car-model"`
+//		Year       int                         `sd:"year"`
+//		TestDrives []slicingdice.Event[string] `sd:"test-drives"`
+//	}
+//
+// The "entity-id" field, a string or an integer, names the entity and is
+// required: an empty string or a zero integer is rejected. The "dimension"
+// field names its dimension. Times are written in RFC 3339 format, in UTC.
+// Nil pointers, slices, maps and interfaces and zero times are left out, as
+// are zero values of fields tagged omitempty. Untagged fields are ignored.
+func MarshalInsert[T any](entities []T, opts ...InsertOption) (map[string]interface{}, error) {
+	insert := make(map[string]interface{}, len(entities)+1)
+	for i, entity := range entities {
+		v := reflect.ValueOf(entity)
+		for v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				return nil, fmt.Errorf("insert: entity %d is nil", i)
+			}
+			v = v.Elem()
+		}
+		if v.Kind() != reflect.Struct {
+			return nil, fmt.Errorf("insert: entity %d: %s is not a struct", i, v.Type())
+		}
+		var id string
+		columns := make(map[string]interface{})
+		for _, f := range rowFields(v.Type()) {
+			field := v.Field(f.index)
+			if f.column == "entity-id" {
+				var ok bool
+				if id, ok = entityID(field); !ok {
+					return nil, fmt.Errorf("insert: entity %d: the entity-id field should be a string or an integer", i)
+				}
+				continue
+			}
+			if omitted(field, f.omitEmpty) {
+				continue
+			}
+			columns[f.column] = insertValue(field)
+		}
+		if id == "" {
+			return nil, fmt.Errorf("insert: entity %d: missing entity ID", i)
+		}
+		if _, ok := insert[id]; ok {
+			return nil, fmt.Errorf("insert: entity %d: duplicate entity ID %q", i, id)
+		}
+		insert[id] = columns
+	}
+	for _, opt := range opts {
+		opt(insert)
+	}
+	return insert, nil
+}
+
+// entityID returns the entity ID held by field, empty if it is a zero
+// integer.
+func entityID(field reflect.Value) (string, bool) {
+	switch field.Kind() {
+	case reflect.String:
+		return field.String(), true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if field.Int() == 0 {
+			return "", true
+		}
+		return strconv.FormatInt(field.Int(), 10), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if field.Uint() == 0 {
+			return "", true
+		}
+		return strconv.FormatUint(field.Uint(), 10), true
+	}
+	return "", false
+}
+
+// omitted reports whether field is left out of the insert.
+func omitted(field reflect.Value, omitEmpty bool) bool {
+	switch field.Kind() {
+	case reflect.Pointer, reflect.Slice, reflect.Map, reflect.Interface:
+		if field.IsNil() {
+			return true
+		}
+	}
+	if omitEmpty && field.IsZero() {
+		return true
+	}
+	for field.Kind() == reflect.Pointer && !field.IsNil() {
+		field = field.Elem()
+	}
+	// An unset time would be sent as 0001-01-01T00:00:00Z.
+	t, ok := field.Interface().(time.Time)
+	return ok && t.IsZero()
+}
+
+// insertValue returns the value held by v as written in an insert. Times
+// are written in RFC 3339 format, in UTC.
+func insertValue(v reflect.Value) interface{} {
+	for v.Kind() == reflect.Pointer && !v.IsNil() {
+		v = v.Elem()
+	}
+	if !v.IsValid() {
+		return nil
+	}
+	if t, ok := v.Interface().(time.Time); ok {
+		return t.UTC().Format(time.RFC3339)
+	}
+	if v.Kind() == reflect.Slice && v.Type().Elem() == timeType {
+		times := make([]string, v.Len())
+		for i := range times {
+			times[i] = v.Index(i).Interface().(time.Time).UTC().Format(time.RFC3339)
+		}
+		return times
+	}
+	return v.Interface()
+}
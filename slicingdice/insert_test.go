@@ -0,0 +1,108 @@
+package slicingdice
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+type insertCar struct {
+	ID         string          `sd:"entity-id"`
+	Dimension  string          `sd:"dimension,omitempty"`
+	Model      string          `sd:"car-model"`
+	Year       int             `sd:"year"`
+	Price      *float64        `sd:"price"`
+	Sold       time.Time       `sd:"sold-at"`
+	Serviced   *time.Time      `sd:"serviced-at"`
+	Owners     []string        `sd:"owners"`
+	Color      string          `sd:"color,omitempty"`
+	TestDrives []Event[string] `sd:"test-drives"`
+	Note       string
+}
+
+type insertUser struct {
+	ID  int64 `sd:"entity-id"`
+	Age uint  `sd:"age"`
+}
+
+func TestMarshalInsert(t *testing.T) {
+	date := time.Date(2016, 8, 17, 13, 23, 47, 0, time.FixedZone("UTC+1", 3600))
+	price := 9.5
+	var zero time.Time
+	tests := []struct {
+		name    string
+		marshal func() (map[string]interface{}, error)
+		want    string
+		err     string
+	}{
+		{"scalars", func() (map[string]interface{}, error) {
+			return MarshalInsert([]insertCar{{ID: "c1", Model: "Ford Ka", Year: 2016, Note: "ignored"}})
+		}, `{"c1": {"car-model": "Ford Ka", "year": 2016}}`, ""},
+		{"pointers", func() (map[string]interface{}, error) {
+			return MarshalInsert([]*insertCar{{ID: "c1", Price: &price, Serviced: &date}})
+		}, `{"c1": {"car-model": "", "year": 0, "price": 9.5, "serviced-at": "2016-08-17T12:23:47Z"}}`, ""},
+		{"times", func() (map[string]interface{}, error) {
+			return MarshalInsert([]insertCar{{ID: "c1", Sold: date, Serviced: &zero}})
+		}, `{"c1": {"car-model": "", "year": 0, "sold-at": "2016-08-17T12:23:47Z"}}`, ""},
+		{"events", func() (map[string]interface{}, error) {
+			return MarshalInsert([]insertCar{{ID: "c1", TestDrives: []Event[string]{{"NY", date}, {"SF", date}}}})
+		}, `{"c1": {"car-model": "", "year": 0, "test-drives": [{"value": "NY", "date": "2016-08-17T12:23:47Z"}, {"value": "SF", "date": "2016-08-17T12:23:47Z"}]}}`, ""},
+		{"omitempty", func() (map[string]interface{}, error) {
+			return MarshalInsert([]insertCar{{ID: "c1", Dimension: "cars", Owners: []string{"a"}}, {ID: "c2", Color: "red"}})
+		}, `{"c1": {"dimension": "cars", "car-model": "", "year": 0, "owners": ["a"]}, "c2": {"car-model": "", "year": 0, "color": "red"}}`, ""},
+		{"integer id", func() (map[string]interface{}, error) {
+			return MarshalInsert([]insertUser{{ID: 7, Age: 30}})
+		}, `{"7": {"age": 30}}`, ""},
+		{"auto-create", func() (map[string]interface{}, error) {
+			return MarshalInsert([]insertUser{{ID: 7}}, AutoCreate(AUTO_CREATE_DIMENSION, AUTO_CREATE_COLUMN))
+		}, `{"7": {"age": 0}, "auto-create": ["dimension", "column"]}`, ""},
+		{"missing id", func() (map[string]interface{}, error) {
+			return MarshalInsert([]insertCar{{Model: "Ford Ka"}})
+		}, "", "entity 0: missing entity ID"},
+		{"zero integer id", func() (map[string]interface{}, error) {
+			return MarshalInsert([]insertUser{{ID: 1}, {Age: 30}})
+		}, "", "entity 1: missing entity ID"},
+		{"duplicate id", func() (map[string]interface{}, error) {
+			return MarshalInsert([]insertCar{{ID: "c1"}, {ID: "c1"}})
+		}, "", `entity 1: duplicate entity ID "c1"`},
+		{"nil entity", func() (map[string]interface{}, error) {
+			return MarshalInsert([]*insertCar{nil})
+		}, "", "entity 0 is nil"},
+		{"not a struct", func() (map[string]interface{}, error) {
+			return MarshalInsert([]string{"c1"})
+		}, "", "entity 0: string is not a struct"},
+		{"bad id type", func() (map[string]interface{}, error) {
+			return MarshalInsert([]struct {
+				ID float64 `sd:"entity-id"`
+			}{{1}})
+		}, "", "entity 0: the entity-id field should be a string or an integer"},
+	}
+	for _, tt := range tests {
+		insert, err := tt.marshal()
+		if tt.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("%s: error = %v, want %q", tt.name, err, tt.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		data, err := json.Marshal(insert)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got, want interface{}
+		json.Unmarshal(data, &got)
+		if err := json.Unmarshal([]byte(tt.want), &want); err != nil {
+			t.Fatal(err)
+		}
+		gotJSON, _ := json.Marshal(got)
+		wantJSON, _ := json.Marshal(want)
+		if string(gotJSON) != string(wantJSON) {
+			t.Errorf("%s:\n got %s\nwant %s", tt.name, gotJSON, wantJSON)
+		}
+	}
+}
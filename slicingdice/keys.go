@@ -0,0 +1,146 @@
+package slicingdice
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// Permission levels of the API keys. A call needs a key of the level its
+// endpoint requires; master and custom keys can perform every call.
+const (
+	READ_KEY_LEVEL   = 0
+	WRITE_KEY_LEVEL  = 1
+	MASTER_KEY_LEVEL = 2
+)
+
+// endpointKeyLevels maps the method and endpoint of every call to the key
+// level it requires. namedQuery marks calls made on a single saved query.
+var endpointKeyLevels = map[string]int{
+	"GET " + DATABASE:              MASTER_KEY_LEVEL,
+	"GET " + COLUMN:                MASTER_KEY_LEVEL,
+	"POST " + COLUMN:               WRITE_KEY_LEVEL,
+	"GET " + SAVED:                 MASTER_KEY_LEVEL,
+	"POST " + SAVED:                MASTER_KEY_LEVEL,
+	"GET " + SAVED + namedQuery:    READ_KEY_LEVEL,
+	"PUT " + SAVED + namedQuery:    MASTER_KEY_LEVEL,
+	"DELETE " + SAVED + namedQuery: MASTER_KEY_LEVEL,
+	"POST " + INSERT:               WRITE_KEY_LEVEL,
+	"POST " + COUNT_ENTITY:         READ_KEY_LEVEL,
+	"POST " + COUNT_ENTITY_TOTAL:   READ_KEY_LEVEL,
+	"POST " + COUNT_EVENT:          READ_KEY_LEVEL,
+	"POST " + AGGREGATION:          READ_KEY_LEVEL,
+	"POST " + RESULT:               READ_KEY_LEVEL,
+	"POST " + SCORE:                READ_KEY_LEVEL,
+	"POST " + TOP_VALUES:           READ_KEY_LEVEL,
+	"POST " + EXISTS_ENTITY:        READ_KEY_LEVEL,
+	"POST " + SQL:                  READ_KEY_LEVEL,
+	"POST " + DELETE:               WRITE_KEY_LEVEL,
+	"POST " + UPDATE:               WRITE_KEY_LEVEL,
+}
+
+// namedQuery is appended to SAVED in endpointKeyLevels for calls whose path
+// ends with a saved query name.
+const namedQuery = "{name}"
+
+// keyLevelOf returns the key level required by c. SQL statements that are
+// not read-only, as told by sqlReadOnly, need the write key. Calls missing
+// from endpointKeyLevels need the master key.
+func keyLevelOf(c *Call) int {
+	if c.Endpoint == SQL {
+		if statement, _ := c.Query.(string); !sqlReadOnly(statement) {
+			return WRITE_KEY_LEVEL
+		}
+	}
+	route := c.Method + " " + c.Endpoint
+	if c.Path != c.Endpoint {
+		route += namedQuery
+	}
+	if level, ok := endpointKeyLevels[route]; ok {
+		return level
+	}
+	return MASTER_KEY_LEVEL
+}
+
+// setKey keeps the keys given to the client.
+func (s *SlicingDice) setKey(key *APIKey) {
+	s.credentials = StaticCredentials(key)
+}
+
+// keyFor returns the key used to perform c: a key of the level c requires
+// if set, otherwise a master key. The level of a key is read from its claims
+// when it has any, so a key is never sent to a call it cannot authorize,
+// whichever APIKey field holds it; other keys get the level of their field,
+// the custom key counting as a master key. It fails naming the missing key
+// type when the client has none of them, or the fields holding a key whose
+// claims grant a lower level than their own. The keys are asked to the client
+// credential provider, or the test mode one in test mode.
+func (s *SlicingDice) keyFor(ctx context.Context, c *Call) (string, error) {
+	credentials := s.credentials
+	if s.Test {
+		credentials = s.testMode.Credentials
+	}
+	current := new(APIKey)
+	if credentials != nil {
+		var err error
+		if current, err = credentials.Credentials(ctx); err != nil {
+			return "", fmt.Errorf("API key: %w", err)
+		}
+		if current == nil {
+			current = new(APIKey)
+		}
+	}
+	keys := []struct {
+		field string
+		key   string
+		level int
+	}{
+		{"ReadKey", current.ReadKey, READ_KEY_LEVEL},
+		{"WriteKey", current.WriteKey, WRITE_KEY_LEVEL},
+		{"MasterKey", current.MasterKey, MASTER_KEY_LEVEL},
+		{"CustomKey", current.CustomKey, MASTER_KEY_LEVEL},
+	}
+	var lowered []string
+	for i := range keys {
+		if len(keys[i].key) == 0 {
+			continue
+		}
+		fieldLevel := keys[i].level
+		keys[i].level = grantedKeyLevel(keys[i].key, fieldLevel)
+		if keys[i].level != fieldLevel && (fieldLevel == c.KeyLevel || fieldLevel == MASTER_KEY_LEVEL) {
+			lowered = append(lowered, fmt.Sprintf("the claims of %s grant a %s key", keys[i].field, keyLevelName(keys[i].level)))
+		}
+	}
+	for _, level := range []int{c.KeyLevel, MASTER_KEY_LEVEL} {
+		for _, k := range keys {
+			if len(k.key) != 0 && k.level == level {
+				return k.key, nil
+			}
+		}
+	}
+	name := keyLevelName(c.KeyLevel)
+	if len(lowered) != 0 {
+		return "", fmt.Errorf("API key: %w: %s %s needs a %s key, but %s", ErrMissingKey, c.Method, c.Endpoint, name, strings.Join(lowered, " and "))
+	}
+	var fields string
+	switch c.KeyLevel {
+	case READ_KEY_LEVEL:
+		fields = "ReadKey, MasterKey or CustomKey"
+	case WRITE_KEY_LEVEL:
+		fields = "WriteKey, MasterKey or CustomKey"
+	default:
+		fields = "MasterKey or CustomKey"
+	}
+	return "", fmt.Errorf("API key: %w: %s %s needs a %s key, set %s", ErrMissingKey, c.Method, c.Endpoint, name, fields)
+}
+
+// keyLevelName returns the name of a key level, e.g. "read".
+func keyLevelName(level int) string {
+	switch level {
+	case READ_KEY_LEVEL:
+		return "read"
+	case WRITE_KEY_LEVEL:
+		return "write"
+	}
+	return "master"
+}
@@ -0,0 +1,109 @@
+package slicingdice
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestKeyLevelOf(t *testing.T) {
+	tests := []struct {
+		name string
+		call Call
+		want int
+	}{
+		{"database", Call{Endpoint: DATABASE, Path: DATABASE, Method: "GET"}, MASTER_KEY_LEVEL},
+		{"list columns", Call{Endpoint: COLUMN, Path: COLUMN, Method: "GET"}, MASTER_KEY_LEVEL},
+		{"create column", Call{Endpoint: COLUMN, Path: COLUMN, Method: "POST"}, WRITE_KEY_LEVEL},
+		{"insert", Call{Endpoint: INSERT, Path: INSERT, Method: "POST"}, WRITE_KEY_LEVEL},
+		{"count entity", Call{Endpoint: COUNT_ENTITY, Path: COUNT_ENTITY, Method: "POST"}, READ_KEY_LEVEL},
+		{"get saved query", Call{Endpoint: SAVED, Path: SAVED + "q", Method: "GET"}, READ_KEY_LEVEL},
+		{"list saved queries", Call{Endpoint: SAVED, Path: SAVED, Method: "GET"}, MASTER_KEY_LEVEL},
+		{"delete saved query", Call{Endpoint: SAVED, Path: SAVED + "q", Method: "DELETE"}, MASTER_KEY_LEVEL},
+		{"delete", Call{Endpoint: DELETE, Path: DELETE, Method: "POST"}, WRITE_KEY_LEVEL},
+		{"update", Call{Endpoint: UPDATE, Path: UPDATE, Method: "POST"}, WRITE_KEY_LEVEL},
+		{"unknown", Call{Endpoint: "/other/", Path: "/other/", Method: "POST"}, MASTER_KEY_LEVEL},
+		{"sql select", Call{Endpoint: SQL, Path: SQL, Method: "POST", Query: "select 1"}, READ_KEY_LEVEL},
+		{"sql with", Call{Endpoint: SQL, Path: SQL, Method: "POST", Query: "WITH x AS (SELECT 1) SELECT * FROM x"}, READ_KEY_LEVEL},
+		{"sql union", Call{Endpoint: SQL, Path: SQL, Method: "POST", Query: "(SELECT a FROM t) UNION (SELECT a FROM u)"}, READ_KEY_LEVEL},
+		{"sql comment", Call{Endpoint: SQL, Path: SQL, Method: "POST", Query: "-- report\nSELECT COUNT(*) FROM t"}, READ_KEY_LEVEL},
+		{"sql literal", Call{Endpoint: SQL, Path: SQL, Method: "POST", Query: "SELECT * FROM t WHERE a = 'delete'"}, READ_KEY_LEVEL},
+		{"sql bracketed column", Call{Endpoint: SQL, Path: SQL, Method: "POST", Query: "SELECT * FROM users WHERE [last-update.date] > '2017-01-01'"}, READ_KEY_LEVEL},
+		{"sql bracketed dimension", Call{Endpoint: SQL, Path: SQL, Method: "POST", Query: "SELECT [entity-id] FROM [delete-test]"}, READ_KEY_LEVEL},
+		{"sql replace function", Call{Endpoint: SQL, Path: SQL, Method: "POST", Query: "SELECT REPLACE(name,'a','b') FROM users"}, READ_KEY_LEVEL},
+		{"sql recursive with", Call{Endpoint: SQL, Path: SQL, Method: "POST", Query: "WITH RECURSIVE a (n) AS (SELECT 1), b AS ((SELECT n FROM a)) SELECT * FROM b;"}, READ_KEY_LEVEL},
+		{"sql insert", Call{Endpoint: SQL, Path: SQL, Method: "POST", Query: "INSERT INTO t VALUES (1)"}, WRITE_KEY_LEVEL},
+		{"sql second statement", Call{Endpoint: SQL, Path: SQL, Method: "POST", Query: "SELECT 1; DELETE FROM t"}, WRITE_KEY_LEVEL},
+		{"sql with delete body", Call{Endpoint: SQL, Path: SQL, Method: "POST", Query: "WITH d AS (DELETE FROM t RETURNING *) SELECT * FROM d"}, WRITE_KEY_LEVEL},
+		{"sql unterminated bracket", Call{Endpoint: SQL, Path: SQL, Method: "POST", Query: "SELECT [entity-id FROM t"}, WRITE_KEY_LEVEL},
+		{"sql empty", Call{Endpoint: SQL, Path: SQL, Method: "POST", Query: " ; "}, WRITE_KEY_LEVEL},
+		{"sql commented delete", Call{Endpoint: SQL, Path: SQL, Method: "POST", Query: "/* x */ DELETE FROM t"}, WRITE_KEY_LEVEL},
+		{"sql with delete", Call{Endpoint: SQL, Path: SQL, Method: "POST", Query: "WITH a AS (SELECT 1) DELETE FROM t"}, WRITE_KEY_LEVEL},
+		{"sql select into", Call{Endpoint: SQL, Path: SQL, Method: "POST", Query: "SELECT * INTO u FROM t"}, WRITE_KEY_LEVEL},
+		{"sql unterminated", Call{Endpoint: SQL, Path: SQL, Method: "POST", Query: "SELECT 'a"}, WRITE_KEY_LEVEL},
+	}
+	for _, tt := range tests {
+		if got := keyLevelOf(&tt.call); got != tt.want {
+			t.Errorf("%s: keyLevelOf() = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestKeyFor(t *testing.T) {
+	readJWT := testJWT(`{"permission_level":1}`)
+	masterJWT := testJWT(`{"permission_level":3}`)
+	tests := []struct {
+		name    string
+		keys    APIKey
+		level   int
+		want    string
+		err     error
+		message string
+	}{
+		{"read key for read", APIKey{ReadKey: "r", WriteKey: "w"}, READ_KEY_LEVEL, "r", nil, ""},
+		{"write key for write", APIKey{ReadKey: "r", WriteKey: "w"}, WRITE_KEY_LEVEL, "w", nil, ""},
+		{"master key as fallback", APIKey{ReadKey: "r", MasterKey: "m"}, WRITE_KEY_LEVEL, "m", nil, ""},
+		{"custom key as fallback", APIKey{CustomKey: "c"}, READ_KEY_LEVEL, "c", nil, ""},
+		{"least powerful key first", APIKey{ReadKey: "r", MasterKey: "m"}, READ_KEY_LEVEL, "r", nil, ""},
+		{"no write key", APIKey{ReadKey: "r"}, WRITE_KEY_LEVEL, "", ErrMissingKey, "set WriteKey, MasterKey or CustomKey"},
+		{"no read key", APIKey{WriteKey: "w"}, READ_KEY_LEVEL, "", ErrMissingKey, "set ReadKey, MasterKey or CustomKey"},
+		{"no master key", APIKey{ReadKey: "r", WriteKey: "w"}, MASTER_KEY_LEVEL, "", ErrMissingKey, "set MasterKey or CustomKey"},
+		{"no key", APIKey{}, READ_KEY_LEVEL, "", ErrMissingKey, "set ReadKey, MasterKey or CustomKey"},
+		{"read claims in master key", APIKey{MasterKey: readJWT}, WRITE_KEY_LEVEL, "", ErrMissingKey, "the claims of MasterKey grant a read key"},
+		{"read claims in write key", APIKey{WriteKey: readJWT, CustomKey: readJWT}, WRITE_KEY_LEVEL, "", ErrMissingKey,
+			"the claims of WriteKey grant a read key and the claims of CustomKey grant a read key"},
+		{"read claims for read", APIKey{MasterKey: readJWT}, READ_KEY_LEVEL, readJWT, nil, ""},
+		{"master claims in read key", APIKey{ReadKey: masterJWT}, MASTER_KEY_LEVEL, masterJWT, nil, ""},
+	}
+	for _, tt := range tests {
+		s := NewClient(WithAPIKey(&tt.keys))
+		got, err := s.keyFor(context.Background(), &Call{Endpoint: COUNT_ENTITY, Method: "POST", KeyLevel: tt.level})
+		if got != tt.want || !errors.Is(err, tt.err) || err != nil && !strings.Contains(err.Error(), tt.message) {
+			t.Errorf("%s: keyFor() = %q, %v, want %q, %v %q", tt.name, got, err, tt.want, tt.err, tt.message)
+		}
+	}
+}
+
+func TestGrantedKeyLevelCache(t *testing.T) {
+	key := testJWT(`{"permission_level":2}`)
+	for i := 0; i < 2; i++ {
+		if got := grantedKeyLevel(key, MASTER_KEY_LEVEL); got != WRITE_KEY_LEVEL {
+			t.Errorf("grantedKeyLevel() = %d, want %d", got, WRITE_KEY_LEVEL)
+		}
+		if level, _ := claimedKeyLevels.Load(key); level != WRITE_KEY_LEVEL {
+			t.Errorf("cached level = %v, want %d", level, WRITE_KEY_LEVEL)
+		}
+	}
+	for _, fallback := range []int{READ_KEY_LEVEL, MASTER_KEY_LEVEL} {
+		if got := grantedKeyLevel("opaque", fallback); got != fallback {
+			t.Errorf("grantedKeyLevel() of a key without claims = %d, want %d", got, fallback)
+		}
+	}
+}
+
+// testJWT returns a key carrying claims.
+func testJWT(claims string) string {
+	return "eyJhbGciOiJIUzI1NiJ9." + base64.RawURLEncoding.EncodeToString([]byte(claims)) + ".signature"
+}
@@ -0,0 +1,211 @@
+package slicingdice
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+// Redaction selects what is removed from the payloads logged by the client
+// at debug level. API keys are never logged.
+type Redaction int
+
+const (
+	// RedactEntityIDs hides entity IDs: the keys of an insert and the
+	// values of the "ids" and "entity-id" fields.
+	RedactEntityIDs Redaction = 1 << iota
+	// RedactValues hides column values in inserts, queries and SQL
+	// statements.
+	RedactValues
+)
+
+// REDACTED replaces the redacted parts of logged payloads.
+const REDACTED = "[REDACTED]"
+
+// WithLogRedaction sets what is removed from the logged payloads.
+func WithLogRedaction(redaction Redaction) Option {
+	return func(s *SlicingDice) {
+		s.redaction = redaction
+	}
+}
+
+// structuralKeys name the query fields that never hold column values and are
+// therefore kept when values are redacted.
+var structuralKeys = map[string]bool{
+	"query-name":   true,
+	"dimension":    true,
+	"dimensions":   true,
+	"columns":      true,
+	"limit":        true,
+	"order":        true,
+	"page":         true,
+	"type":         true,
+	"name":         true,
+	"auto-create":  true,
+	"bypass-cache": true,
+}
+
+// entityIDKeys name the query fields that hold entity IDs.
+var entityIDKeys = map[string]bool{
+	"ids":       true,
+	"entity-id": true,
+}
+
+// sqlLiteral matches the string and number literals of a SQL statement.
+var sqlLiteral = regexp.MustCompile(`'(?:[^']|'')*'|\b\d+(?:\.\d+)?\b`)
+
+// logPayload logs the query of a call at debug level, after redaction.
+func (s *SlicingDice) logPayload(ctx context.Context, c *Call) {
+	if s.logger == nil || !s.logger.Enabled(ctx, slog.LevelDebug) {
+		return
+	}
+	s.logger.DebugContext(ctx, "slicingdice: request payload",
+		"endpoint", c.Endpoint,
+		"method", c.Method,
+		"key_level", c.KeyLevel,
+		"headers", redactHeader(c.Header),
+		"payload", redactPayload(c.Endpoint, c.Query, s.redaction))
+}
+
+// logAttempt logs each request sent, including retries, at debug level.
+func (s *SlicingDice) logAttempt(ctx context.Context, c *Call, duration time.Duration, err error) {
+	if s.logger == nil {
+		return
+	}
+	attrs := []any{
+		"endpoint", c.Endpoint,
+		"method", c.Method,
+		"attempt", c.Attempts,
+		"status", c.StatusCode,
+		"duration", duration,
+	}
+	if err != nil {
+		attrs = append(attrs, "error", err)
+	}
+	s.logger.DebugContext(ctx, "slicingdice: request attempt", attrs...)
+}
+
+// logCall logs the outcome of a call, at info level when it succeeded and
+// at warn level when it failed.
+func (s *SlicingDice) logCall(ctx context.Context, c *Call, duration time.Duration, err error) {
+	attrs := []any{
+		"endpoint", c.Endpoint,
+		"method", c.Method,
+		"status", c.StatusCode,
+		"attempts", c.Attempts,
+		"duration", duration,
+	}
+	if err == nil {
+		s.logger.InfoContext(ctx, "slicingdice: request", attrs...)
+		return
+	}
+	var sdErr *SDError
+	if errors.As(err, &sdErr) {
+		attrs = append(attrs, "error_code", sdErr.Code)
+	}
+	attrs = append(attrs, "error", err)
+	s.logger.WarnContext(ctx, "slicingdice: request failed", attrs...)
+}
+
+// redactHeader returns a copy of header with the Authorization header
+// redacted.
+func redactHeader(header http.Header) http.Header {
+	redacted := header.Clone()
+	if redacted == nil {
+		return http.Header{}
+	}
+	if _, ok := redacted["Authorization"]; ok {
+		redacted.Set("Authorization", REDACTED)
+	}
+	return redacted
+}
+
+// redactPayload returns a copy of query with the parts selected by redaction
+// replaced by REDACTED.
+func redactPayload(endpoint string, query interface{}, redaction Redaction) interface{} {
+	if endpoint == SQL {
+		statement, _ := query.(string)
+		if redaction&RedactValues != 0 {
+			return sqlLiteral.ReplaceAllString(statement, "?")
+		}
+		return statement
+	}
+	if redaction == 0 {
+		return query
+	}
+	// Work on a generic copy, so typed maps and slices are handled too.
+	var payload interface{}
+	data, err := json.Marshal(query)
+	if err != nil || json.Unmarshal(data, &payload) != nil {
+		return REDACTED
+	}
+	if endpoint == INSERT {
+		if entities, ok := payload.(map[string]interface{}); ok {
+			return redactInsert(entities, redaction)
+		}
+	}
+	return redactValue("", payload, redaction, false)
+}
+
+// redactInsert redacts an insert payload, whose keys are entity IDs mapped
+// to their column values.
+func redactInsert(entities map[string]interface{}, redaction Redaction) map[string]interface{} {
+	redacted := make(map[string]interface{}, len(entities))
+	n := 0
+	for id, columns := range entities {
+		if structuralKeys[id] {
+			redacted[id] = columns
+			continue
+		}
+		if redaction&RedactEntityIDs != 0 {
+			n++
+			id = REDACTED + "-" + strconv.Itoa(n)
+		}
+		redacted[id] = redactValue("", columns, redaction, false)
+	}
+	return redacted
+}
+
+// redactValue redacts value, found under key. Scalars are redacted when
+// they are column values or, if ids is set, entity IDs.
+func redactValue(key string, value interface{}, redaction Redaction, ids bool) interface{} {
+	if structuralKeys[key] {
+		return value
+	}
+	ids = ids || (entityIDKeys[key] && redaction&RedactEntityIDs != 0)
+	switch v := value.(type) {
+	case map[string]interface{}:
+		redacted := make(map[string]interface{}, len(v))
+		for k, item := range v {
+			redacted[k] = redactValue(k, item, redaction, ids)
+		}
+		return redacted
+	case []interface{}:
+		redacted := make([]interface{}, len(v))
+		for i, item := range v {
+			if operator, ok := item.(string); ok && !ids && isOperator(operator) {
+				redacted[i] = operator
+				continue
+			}
+			redacted[i] = redactValue(key, item, redaction, ids)
+		}
+		return redacted
+	case nil:
+		return nil
+	}
+	if ids || redaction&RedactValues != 0 {
+		return REDACTED
+	}
+	return value
+}
+
+// isOperator reports whether s is one of the boolean operators that join
+// the conditions of a query.
+func isOperator(s string) bool {
+	return s == "and" || s == "or" || s == "not"
+}
@@ -0,0 +1,34 @@
+package slicingdice
+
+import "context"
+
+// Handler sends a call to the SlicingDice API and returns the decoded
+// response.
+type Handler func(ctx context.Context, call *Call) (map[string]interface{}, error)
+
+// Interceptor wraps every call made by a client. It may inspect or change
+// the call, e.g. to add headers, before passing it to next, and inspect or
+// change the response and error next returns. It may also short-circuit the
+// call by returning without calling next.
+type Interceptor func(ctx context.Context, call *Call, next Handler) (map[string]interface{}, error)
+
+// WithInterceptors appends interceptors to the client. They run in the order
+// given, the first one being the outermost. Interceptors see each call once,
+// with retries happening inside next.
+func WithInterceptors(interceptors ...Interceptor) Option {
+	return func(s *SlicingDice) {
+		s.interceptors = append(s.interceptors, interceptors...)
+	}
+}
+
+// chain returns a Handler that runs the client interceptors around sendCall.
+func (s *SlicingDice) chain() Handler {
+	handler := Handler(s.sendCall)
+	for i := len(s.interceptors) - 1; i >= 0; i-- {
+		interceptor, next := s.interceptors[i], handler
+		handler = func(ctx context.Context, call *Call) (map[string]interface{}, error) {
+			return interceptor(ctx, call, next)
+		}
+	}
+	return handler
+}
@@ -0,0 +1,197 @@
+package slicingdice
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// Option configures a SlicingDice client when it is created with NewClient
+// or New.
+type Option func(*SlicingDice)
+
+// transportConfig holds the settings used to build the client's own
+// http.Transport when no HTTP client or round tripper was injected.
+type transportConfig struct {
+	maxIdleConns        int
+	maxIdleConnsPerHost int
+	maxConnsPerHost     int
+	idleConnTimeout     time.Duration
+	disableHTTP2        bool
+
+	rootCAs            *x509.CertPool
+	certificates       []tls.Certificate
+	minTLSVersion      uint16
+	insecureSkipVerify bool
+}
+
+func defaultTransportConfig() transportConfig {
+	return transportConfig{
+		maxIdleConns:        100,
+		maxIdleConnsPerHost: 10,
+		idleConnTimeout:     90 * time.Second,
+		minTLSVersion:       tls.VersionTLS12,
+	}
+}
+
+// WithBaseURL sets the address of the SlicingDice API, such as
+// "https://api.slicingdice.com/v1".
+func WithBaseURL(url string) Option {
+	return func(s *SlicingDice) {
+		s.baseURL = url
+	}
+}
+
+// WithAPIKey sets the keys used to authenticate requests.
+func WithAPIKey(key *APIKey) Option {
+	return func(s *SlicingDice) {
+		s.setKey(key)
+	}
+}
+
+// WithTimeout sets how long to wait for each request. Zero means no timeout
+// other than the one of the context passed to the request.
+func WithTimeout(timeout time.Duration) Option {
+	return func(s *SlicingDice) {
+		s.timeout = timeout
+	}
+}
+
+// WithUserAgent sets the User-Agent header sent with every request.
+func WithUserAgent(userAgent string) Option {
+	return func(s *SlicingDice) {
+		s.userAgent = userAgent
+	}
+}
+
+// WithLogger sets the logger the client reports its activity to. Nothing is
+// logged by default.
+func WithLogger(logger *slog.Logger) Option {
+	return func(s *SlicingDice) {
+		s.logger = logger
+	}
+}
+
+// WithHTTPClient makes the client send every request through c instead of
+// building its own. Transport and TLS options are ignored when it is set.
+func WithHTTPClient(c *http.Client) Option {
+	return func(s *SlicingDice) {
+		s.httpClient = c
+	}
+}
+
+// WithRoundTripper makes the client use rt as the transport of its own
+// http.Client. Transport and TLS options are ignored when it is set.
+func WithRoundTripper(rt http.RoundTripper) Option {
+	return func(s *SlicingDice) {
+		s.roundTripper = rt
+	}
+}
+
+// WithMaxIdleConns sets the maximum number of idle (keep-alive) connections
+// kept across all hosts. Zero means no limit.
+func WithMaxIdleConns(n int) Option {
+	return func(s *SlicingDice) {
+		s.transport.maxIdleConns = n
+	}
+}
+
+// WithMaxIdleConnsPerHost sets the maximum number of idle (keep-alive)
+// connections kept per host.
+func WithMaxIdleConnsPerHost(n int) Option {
+	return func(s *SlicingDice) {
+		s.transport.maxIdleConnsPerHost = n
+	}
+}
+
+// WithMaxConnsPerHost limits the total number of connections per host,
+// including connections in the dialing, active, and idle states. Zero means
+// no limit.
+func WithMaxConnsPerHost(n int) Option {
+	return func(s *SlicingDice) {
+		s.transport.maxConnsPerHost = n
+	}
+}
+
+// WithIdleConnTimeout sets how long an idle connection is kept in the pool
+// before being closed. Zero means no limit.
+func WithIdleConnTimeout(d time.Duration) Option {
+	return func(s *SlicingDice) {
+		s.transport.idleConnTimeout = d
+	}
+}
+
+// WithHTTP2 enables or disables HTTP/2. It is enabled by default.
+func WithHTTP2(enabled bool) Option {
+	return func(s *SlicingDice) {
+		s.transport.disableHTTP2 = !enabled
+	}
+}
+
+// WithRootCAs makes the client verify server certificates against pool
+// instead of the host's root CA set.
+func WithRootCAs(pool *x509.CertPool) Option {
+	return func(s *SlicingDice) {
+		s.transport.rootCAs = pool
+	}
+}
+
+// WithClientCertificates sets the certificates presented to the server for
+// mutual TLS, e.g. when talking to an on-premises gateway.
+func WithClientCertificates(certs ...tls.Certificate) Option {
+	return func(s *SlicingDice) {
+		s.transport.certificates = append(s.transport.certificates, certs...)
+	}
+}
+
+// WithMinTLSVersion sets the minimum TLS version accepted, such as
+// tls.VersionTLS13. The default is TLS 1.2.
+func WithMinTLSVersion(version uint16) Option {
+	return func(s *SlicingDice) {
+		s.transport.minTLSVersion = version
+	}
+}
+
+// WithInsecureSkipVerifyForTestingOnly disables verification of the server
+// certificate chain and host name. This makes the connection vulnerable to
+// man-in-the-middle attacks and must only be used against local test servers.
+func WithInsecureSkipVerifyForTestingOnly() Option {
+	return func(s *SlicingDice) {
+		s.transport.insecureSkipVerify = true
+	}
+}
+
+// newHTTPClient builds the long-lived http.Client shared by every request
+// made by s, so connections are pooled and reused across calls.
+func (s *SlicingDice) newHTTPClient() *http.Client {
+	if s.httpClient != nil {
+		return s.httpClient
+	}
+	rt := s.roundTripper
+	if rt == nil {
+		rt = s.newTransport()
+	}
+	return &http.Client{Transport: rt}
+}
+
+func (s *SlicingDice) newTransport() *http.Transport {
+	tr := http.DefaultTransport.(*http.Transport).Clone()
+	tr.TLSClientConfig = &tls.Config{
+		RootCAs:            s.transport.rootCAs,
+		Certificates:       s.transport.certificates,
+		MinVersion:         s.transport.minTLSVersion,
+		InsecureSkipVerify: s.transport.insecureSkipVerify,
+	}
+	tr.MaxIdleConns = s.transport.maxIdleConns
+	tr.MaxIdleConnsPerHost = s.transport.maxIdleConnsPerHost
+	tr.MaxConnsPerHost = s.transport.maxConnsPerHost
+	tr.IdleConnTimeout = s.transport.idleConnTimeout
+	tr.ForceAttemptHTTP2 = !s.transport.disableHTTP2
+	if s.transport.disableHTTP2 {
+		// A non-nil, empty TLSNextProto map disables HTTP/2.
+		tr.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+	}
+	return tr
+}
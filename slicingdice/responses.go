@@ -0,0 +1,339 @@
+package slicingdice
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Response holds the fields common to every API response. The To...
+// functions decode a response returned by a client method into a typed
+// value embedding it, e.g.
+//
+//	counts, err := slicingdice.ToCountResult(client.CountEntity(query))
+type Response struct {
+	Status string                 `json:"-"` // "success" unless the API says otherwise
+	Took   time.Duration          `json:"-"` // time the API took to answer, zero if not reported
+	Raw    map[string]interface{} `json:"-"` // the response as returned by the client method
+}
+
+// newResponse returns the common fields of raw.
+func newResponse(raw map[string]interface{}) Response {
+	r := Response{Raw: raw}
+	r.Status, _ = raw["status"].(string)
+	if took, ok := raw["took"].(float64); ok {
+		r.Took = time.Duration(took * float64(time.Second))
+	}
+	return r
+}
+
+// decodeResponse decodes raw into v, a pointer to a struct with JSON tags,
+// and returns the common fields of raw.
+func decodeResponse(raw map[string]interface{}, v interface{}) (Response, error) {
+	r := newResponse(raw)
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return r, fmt.Errorf("response: %w", err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return r, fmt.Errorf("response: %w", err)
+	}
+	return r, nil
+}
+
+// CountResult is the response of CountEntity, CountEntityTotal and
+// CountEvent: the count of every query, by query name.
+type CountResult struct {
+	Response
+	Result map[string]int64 `json:"result"`
+}
+
+// ToCountResult decodes the response of CountEntity, CountEntityTotal or
+// CountEvent. A non-nil err is returned as is.
+func ToCountResult(raw map[string]interface{}, err error) (*CountResult, error) {
+	if err != nil {
+		return nil, err
+	}
+	r := new(CountResult)
+	r.Response, err = decodeResponse(raw, r)
+	return r, err
+}
+
+// TopValue is a value of a column and the number of entities holding it.
+type TopValue struct {
+	Value    interface{} `json:"value"`
+	Quantity int64       `json:"quantity"`
+}
+
+// TopValuesResult is the response of TopValues: by query name, then by
+// column, the most frequent values.
+type TopValuesResult struct {
+	Response
+	Result map[string]map[string][]TopValue `json:"result"`
+}
+
+// ToTopValuesResult decodes the response of TopValues. A non-nil err is
+// returned as is.
+func ToTopValuesResult(raw map[string]interface{}, err error) (*TopValuesResult, error) {
+	if err != nil {
+		return nil, err
+	}
+	r := new(TopValuesResult)
+	r.Response, err = decodeResponse(raw, r)
+	return r, err
+}
+
+// Aggregation is the aggregation of a column: buckets of values when
+// grouping by the column, or metrics such as {"max": 2000000}.
+type Aggregation struct {
+	Buckets []AggregationBucket
+	Metrics map[string]float64
+}
+
+// AggregationBucket is a value of a column, the number of entities holding
+// it and the aggregations of the nested columns over those entities.
+type AggregationBucket struct {
+	Value    interface{}
+	Quantity int64
+	Children map[string]Aggregation
+}
+
+func (a *Aggregation) UnmarshalJSON(data []byte) error {
+	if err := json.Unmarshal(data, &a.Buckets); err == nil {
+		return nil
+	}
+	return json.Unmarshal(data, &a.Metrics)
+}
+
+func (b *AggregationBucket) UnmarshalJSON(data []byte) error {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	for name, value := range fields {
+		var err error
+		switch name {
+		case "value":
+			err = json.Unmarshal(value, &b.Value)
+		case "quantity":
+			err = json.Unmarshal(value, &b.Quantity)
+		default:
+			var child Aggregation
+			if err = json.Unmarshal(value, &child); err == nil {
+				if b.Children == nil {
+					b.Children = make(map[string]Aggregation)
+				}
+				b.Children[name] = child
+			}
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// AggregationResult is the response of Aggregation: the aggregation tree
+// of every column, by column name.
+type AggregationResult struct {
+	Response
+	Result map[string]Aggregation `json:"result"`
+}
+
+// ToAggregationResult decodes the response of Aggregation. A response
+// without a "result" field is read as holding the columns at its top
+// level. A non-nil err is returned as is.
+func ToAggregationResult(raw map[string]interface{}, err error) (*AggregationResult, error) {
+	if err != nil {
+		return nil, err
+	}
+	r := new(AggregationResult)
+	if _, ok := raw["result"]; ok {
+		r.Response, err = decodeResponse(raw, r)
+		return r, err
+	}
+	columns := make(map[string]interface{}, len(raw))
+	for name, value := range raw {
+		if name != "status" && name != "took" {
+			columns[name] = value
+		}
+	}
+	_, err = decodeResponse(map[string]interface{}{"result": columns}, r)
+	r.Response = newResponse(raw)
+	return r, err
+}
+
+// Row is an entity returned by Result or Score, or a row returned by Sql,
+// by column name.
+type Row map[string]interface{}
+
+// EntityID returns the "entity-id" column of r.
+func (r Row) EntityID() string {
+	id, _ := r["entity-id"].(string)
+	return id
+}
+
+// Rows is a list of rows. It also decodes the object form of data
+// extraction results, keyed by entity ID, into rows holding the ID in their
+// "entity-id" column, ordered by ID.
+type Rows []Row
+
+func (r *Rows) UnmarshalJSON(data []byte) error {
+	var list []Row
+	if err := json.Unmarshal(data, &list); err == nil {
+		*r = list
+		return nil
+	}
+	var byID map[string]Row
+	if err := json.Unmarshal(data, &byID); err != nil {
+		return err
+	}
+	ids := make([]string, 0, len(byID))
+	for id := range byID {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	*r = make(Rows, 0, len(ids))
+	for _, id := range ids {
+		row := byID[id]
+		if row == nil {
+			row = Row{}
+		}
+		row["entity-id"] = id
+		*r = append(*r, row)
+	}
+	return nil
+}
+
+// DataExtractionResult is the response of Result and Score: a page of
+// entities and the token of the next page, empty on the last one.
+type DataExtractionResult struct {
+	Response
+	Data     Rows   `json:"data"`
+	NextPage string `json:"next-page"`
+	Page     int    `json:"page"`
+}
+
+// ToDataExtractionResult decodes the response of Result or Score. A
+// non-nil err is returned as is.
+func ToDataExtractionResult(raw map[string]interface{}, err error) (*DataExtractionResult, error) {
+	if err != nil {
+		return nil, err
+	}
+	r := new(DataExtractionResult)
+	r.Response, err = decodeResponse(raw, r)
+	return r, err
+}
+
+// ExistsResult is the response of ExistsEntity: the IDs of the entities
+// that exist and of those that do not.
+type ExistsResult struct {
+	Response
+	Exists    []string `json:"exists"`
+	NotExists []string `json:"not-exists"`
+}
+
+// ToExistsResult decodes the response of ExistsEntity. A non-nil err is
+// returned as is.
+func ToExistsResult(raw map[string]interface{}, err error) (*ExistsResult, error) {
+	if err != nil {
+		return nil, err
+	}
+	r := new(ExistsResult)
+	r.Response, err = decodeResponse(raw, r)
+	return r, err
+}
+
+// InsertResult is the response of Insert.
+type InsertResult struct {
+	Response
+	InsertedEntities int64 `json:"inserted-entities"`
+	InsertedColumns  int64 `json:"inserted-columns"`
+}
+
+// ToInsertResult decodes the response of Insert. A non-nil err is returned
+// as is.
+func ToInsertResult(raw map[string]interface{}, err error) (*InsertResult, error) {
+	if err != nil {
+		return nil, err
+	}
+	r := new(InsertResult)
+	r.Response, err = decodeResponse(raw, r)
+	return r, err
+}
+
+// SQLResult is the response of Sql: the rows returned by the statement and
+// their count.
+type SQLResult struct {
+	Response
+	Result Rows  `json:"result"`
+	Count  int64 `json:"count"`
+}
+
+// ToSQLResult decodes the response of Sql. A non-nil err is returned as
+// is.
+func ToSQLResult(raw map[string]interface{}, err error) (*SQLResult, error) {
+	if err != nil {
+		return nil, err
+	}
+	r := new(SQLResult)
+	r.Response, err = decodeResponse(raw, r)
+	return r, err
+}
+
+// DatabaseInfo is the response of GetDatabase. CreatedAt and UpdatedAt are
+// zero if the API sent none or sent them in an unknown format.
+type DatabaseInfo struct {
+	Response
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	Dimensions  []string  `json:"dimensions"`
+	CreatedAt   time.Time `json:"-"`
+	UpdatedAt   time.Time `json:"-"`
+}
+
+// ToDatabaseInfo decodes the response of GetDatabase. A non-nil err is
+// returned as is.
+func ToDatabaseInfo(raw map[string]interface{}, err error) (*DatabaseInfo, error) {
+	if err != nil {
+		return nil, err
+	}
+	r := new(DatabaseInfo)
+	if r.Response, err = decodeResponse(raw, r); err != nil {
+		return r, err
+	}
+	r.CreatedAt = parseAPITime(raw["created-at"])
+	r.UpdatedAt = parseAPITime(raw["updated-at"])
+	return r, nil
+}
+
+// parseAPITime parses a time sent by the API, with or without a time zone,
+// or returns the zero time.
+func parseAPITime(value interface{}) time.Time {
+	s, _ := value.(string)
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02T15:04:05.999999999"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// ColumnList is the response of GetColumns.
+type ColumnList struct {
+	Response
+	Active   []Column `json:"active"`
+	Inactive []Column `json:"inactive"`
+}
+
+// ToColumnList decodes the response of GetColumns. A non-nil err is
+// returned as is.
+func ToColumnList(raw map[string]interface{}, err error) (*ColumnList, error) {
+	if err != nil {
+		return nil, err
+	}
+	r := new(ColumnList)
+	r.Response, err = decodeResponse(raw, r)
+	return r, err
+}
@@ -0,0 +1,129 @@
+package slicingdice
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy controls how requests that failed for transient reasons, such
+// as connection errors, the client timeout, rate limiting (429) or server
+// errors (5xx), are retried. Only idempotent calls (queries, GetColumns, GetDatabase, ...) are
+// retried unless RetryMutations is set.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts, including the first one.
+	// Values lower than 2 disable retries.
+	MaxAttempts int
+	// InitialBackoff is the delay before the first retry. It doubles on every
+	// following retry, up to MaxBackoff.
+	InitialBackoff time.Duration
+	// MaxBackoff is the longest delay between attempts, zero for no limit. A
+	// request whose Retry-After header asks for a longer delay is not
+	// retried.
+	MaxBackoff time.Duration
+	// Jitter is the fraction, between 0 and 1, by which each delay is
+	// randomly increased or decreased.
+	Jitter float64
+	// RetryMutations also retries calls that change data: Insert, Update,
+	// Delete, CreateColumn and the saved query mutations. A retried mutation
+	// may be applied twice if the first attempt reached the server.
+	RetryMutations bool
+}
+
+// DefaultRetryPolicy returns a policy that makes up to three attempts of
+// idempotent calls, starting with a 200ms backoff.
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:    3,
+		InitialBackoff: 200 * time.Millisecond,
+		MaxBackoff:     5 * time.Second,
+		Jitter:         0.2,
+	}
+}
+
+// WithRetryPolicy sets the retry policy of the client. By default requests
+// are not retried.
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(s *SlicingDice) {
+		s.retry = policy
+	}
+}
+
+// backoff returns how long to wait before retrying after the given attempt.
+// A delay asked by the server through Retry-After takes precedence when it
+// is longer than the computed one. It reports false if that delay exceeds
+// MaxBackoff, in which case the request is not retried.
+func (p RetryPolicy) backoff(attempt int, retryAfter time.Duration) (time.Duration, bool) {
+	if p.MaxBackoff > 0 && retryAfter > p.MaxBackoff {
+		return 0, false
+	}
+	delay := p.InitialBackoff
+	for i := 1; i < attempt && (p.MaxBackoff <= 0 || delay < p.MaxBackoff); i++ {
+		delay *= 2
+	}
+	if p.MaxBackoff > 0 && delay > p.MaxBackoff {
+		delay = p.MaxBackoff
+	}
+	if p.Jitter > 0 {
+		spread := float64(delay) * p.Jitter
+		delay += time.Duration(spread*2*rand.Float64() - spread)
+	}
+	if retryAfter > delay {
+		delay = retryAfter
+	}
+	return delay, true
+}
+
+// isRetryable reports whether a request that failed with err may succeed if
+// sent again. ctx is the context of the whole call: once it is done nothing
+// is retried, but an attempt that only ran out of the client timeout is.
+func isRetryable(ctx context.Context, err error) bool {
+	if ctx.Err() != nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var sdErr *SDError
+	if errors.As(err, &sdErr) {
+		return sdErr.StatusCode == http.StatusTooManyRequests ||
+			(sdErr.StatusCode >= 500 && sdErr.StatusCode != http.StatusNotImplemented)
+	}
+	// Anything else comes from the transport, e.g. a refused or reset
+	// connection.
+	return true
+}
+
+// parseRetryAfter parses a Retry-After header, given either in seconds or as
+// an HTTP date. It returns zero when the header is absent or invalid.
+func parseRetryAfter(value string, now time.Time) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(value); err == nil && date.After(now) {
+		return date.Sub(now)
+	}
+	return 0
+}
+
+// sleepContext waits for d, returning early with the context error if ctx is
+// done first.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
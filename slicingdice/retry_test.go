@@ -0,0 +1,122 @@
+package slicingdice
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestIsRetryable(t *testing.T) {
+	done, cancel := context.WithCancel(context.Background())
+	cancel()
+	attemptTimeout := contextError("POST", "/query/count/entity/", context.DeadlineExceeded)
+	tests := []struct {
+		name string
+		ctx  context.Context
+		err  error
+		want bool
+	}{
+		{"attempt timeout", context.Background(), attemptTimeout, true},
+		{"call context done", done, attemptTimeout, false},
+		{"canceled", context.Background(), contextError("POST", "/query/count/entity/", context.Canceled), false},
+		{"transport", context.Background(), errors.New("connection reset"), true},
+		{"rate limit", context.Background(), &SDError{StatusCode: http.StatusTooManyRequests}, true},
+		{"server error", context.Background(), &SDError{StatusCode: http.StatusBadGateway}, true},
+		{"not implemented", context.Background(), &SDError{StatusCode: http.StatusNotImplemented}, false},
+		{"bad request", context.Background(), &SDError{StatusCode: http.StatusBadRequest}, false},
+	}
+	for _, tt := range tests {
+		if got := isRetryable(tt.ctx, tt.err); got != tt.want {
+			t.Errorf("%s: isRetryable() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestRetryAttemptTimeout(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&requests, 1) == 1 {
+			select {
+			case <-r.Context().Done():
+			case <-time.After(500 * time.Millisecond):
+			}
+			return
+		}
+		w.Write([]byte(`{"status":"success"}`))
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name     string
+		query    func(s *SlicingDice) (map[string]interface{}, error)
+		requests int32
+		err      error
+	}{
+		{"query", func(s *SlicingDice) (map[string]interface{}, error) {
+			return s.CountEntity(map[string]interface{}{"query-name": "q"})
+		}, 2, nil},
+		{"mutation", func(s *SlicingDice) (map[string]interface{}, error) {
+			return s.Insert(map[string]interface{}{})
+		}, 1, ErrTimeout},
+	}
+	for _, tt := range tests {
+		atomic.StoreInt32(&requests, 0)
+		policy := RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond}
+		s := NewClient(WithBaseURL(server.URL), WithAPIKey(&APIKey{MasterKey: "m"}),
+			WithTimeout(50*time.Millisecond), WithRetryPolicy(policy))
+		_, err := tt.query(s)
+		if got := atomic.LoadInt32(&requests); got != tt.requests || !errors.Is(err, tt.err) {
+			t.Errorf("%s: got %d requests and error %v, want %d and %v", tt.name, got, err, tt.requests, tt.err)
+		}
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	policy := RetryPolicy{InitialBackoff: 100 * time.Millisecond, MaxBackoff: time.Second}
+	unlimited := RetryPolicy{InitialBackoff: 100 * time.Millisecond}
+	tests := []struct {
+		name       string
+		policy     RetryPolicy
+		attempt    int
+		retryAfter time.Duration
+		want       time.Duration
+		ok         bool
+	}{
+		{"first retry", policy, 1, 0, 100 * time.Millisecond, true},
+		{"third retry", policy, 3, 0, 400 * time.Millisecond, true},
+		{"capped", policy, 10, 0, time.Second, true},
+		{"longer Retry-After", policy, 1, 500 * time.Millisecond, 500 * time.Millisecond, true},
+		{"shorter Retry-After", policy, 3, 200 * time.Millisecond, 400 * time.Millisecond, true},
+		{"Retry-After at MaxBackoff", policy, 1, time.Second, time.Second, true},
+		{"Retry-After over MaxBackoff", policy, 1, time.Hour, 0, false},
+		{"no MaxBackoff", unlimited, 1, time.Hour, time.Hour, true},
+	}
+	for _, tt := range tests {
+		got, ok := tt.policy.backoff(tt.attempt, tt.retryAfter)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("%s: backoff() = %v, %v, want %v, %v", tt.name, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestRetryAfterOverMaxBackoff(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.Header().Set("Retry-After", "3600")
+		w.WriteHeader(http.StatusTooManyRequests)
+		w.Write([]byte(`{"status":"error","errors":[{"code":1,"message":"too many requests"}]}`))
+	}))
+	defer server.Close()
+
+	s := NewClient(WithBaseURL(server.URL), WithAPIKey(&APIKey{MasterKey: "m"}), WithRetryPolicy(DefaultRetryPolicy()))
+	start := time.Now()
+	_, err := s.GetDatabase()
+	if got := atomic.LoadInt32(&requests); got != 1 || !errors.Is(err, ErrRateLimit) || time.Since(start) > time.Second {
+		t.Errorf("got %d requests and error %v after %v, want 1 request failing with ErrRateLimit right away", got, err, time.Since(start))
+	}
+}
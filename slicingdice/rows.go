@@ -0,0 +1,290 @@
+package slicingdice
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DecodeError reports a column value that cannot be stored in the struct
+// field it is decoded into.
+type DecodeError struct {
+	Row    int         // index of the row
+	Column string      // API name of the column
+	Field  string      // name of the struct field
+	Value  interface{} // value of the column
+	Type   reflect.Type
+	Err    error // cause of the failure, if any
+}
+
+func (e *DecodeError) Error() string {
+	message := fmt.Sprintf("decoding row %d: column %q: cannot store %#v in field %s of type %s", e.Row, e.Column, e.Value, e.Field, e.Type)
+	if e.Err != nil {
+		message += ": " + e.Err.Error()
+	}
+	return message
+}
+
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// rowField is a struct field and the column it holds.
+type rowField struct {
+	index     int
+	column    string
+	omitEmpty bool
+}
+
+// rowFields returns the fields of struct type t tagged with the API name of
+// a column, e.g. `sd:"car-model"` or `sd:"car-model,omitempty"`. Untagged
+// fields and fields tagged `sd:"-"` are ignored.
+func rowFields(t reflect.Type) []rowField {
+	var fields []rowField
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		column, options, _ := strings.Cut(f.Tag.Get("sd"), ",")
+		if column == "" || column == "-" || !f.IsExported() {
+			continue
+		}
+		fields = append(fields, rowField{index: i, column: column, omitEmpty: options == "omitempty"})
+	}
+	return fields
+}
+
+// DecodeRows decodes rows into values of T, a struct whose fields are
+// tagged with the API name of the column they hold:
+//
+//	type Car struct {
+//		ID    string    `sd:"entity-id"`
+//		Model string    `sd:"car-model"`
+//		Year  int       `sd:"year"`
+//		Sold  time.Time `sd:"sold-at"`
+//	}
+//
+// The API returns many values as strings, so values are converted to the
+// field type: numbers from strings such as "2016", booleans from "true" and
+// "false", times from RFC 3339 strings or dates, and strings from numbers
+// and booleans. Lists are decoded into slices element by element, and
+// fields implementing encoding.TextUnmarshaler are decoded from strings.
+// Missing and null columns leave their field unset. A value that cannot be
+// converted returns a *DecodeError.
+func DecodeRows[T any](rows Rows) ([]T, error) {
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	if t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("decoding rows: %s is not a struct", t)
+	}
+	fields := rowFields(t)
+	values := make([]T, len(rows))
+	for i, row := range rows {
+		v := reflect.ValueOf(&values[i]).Elem()
+		for _, f := range fields {
+			value, ok := row[f.column]
+			if !ok || value == nil {
+				continue
+			}
+			if err := coerce(v.Field(f.index), value); err != nil {
+				return nil, &DecodeError{
+					Row:    i,
+					Column: f.column,
+					Field:  t.Field(f.index).Name,
+					Value:  value,
+					Type:   t.Field(f.index).Type,
+					Err:    unwrapCoerceError(err),
+				}
+			}
+		}
+	}
+	return values, nil
+}
+
+// ResultInto decodes the rows of a Result response into values of T, as
+// DecodeRows does. A non-nil err is returned as is:
+//
+//	cars, err := slicingdice.ResultInto[Car](client.Result(query))
+func ResultInto[T any](raw map[string]interface{}, err error) ([]T, error) {
+	r, err := ToDataExtractionResult(raw, err)
+	if err != nil {
+		return nil, err
+	}
+	return DecodeRows[T](r.Data)
+}
+
+// ScoreInto decodes the rows of a Score response into values of T, as
+// DecodeRows does. A non-nil err is returned as is.
+func ScoreInto[T any](raw map[string]interface{}, err error) ([]T, error) {
+	return ResultInto[T](raw, err)
+}
+
+// SQLInto decodes the rows of a Sql response into values of T, as
+// DecodeRows does. A non-nil err is returned as is.
+func SQLInto[T any](raw map[string]interface{}, err error) ([]T, error) {
+	r, err := ToSQLResult(raw, err)
+	if err != nil {
+		return nil, err
+	}
+	return DecodeRows[T](r.Result)
+}
+
+// errIncompatible reports a value of a type that cannot be converted to the
+// field type.
+var errIncompatible = errors.New("incompatible types")
+
+// unwrapCoerceError returns the cause of a conversion failure, nil when the
+// types are incompatible as DecodeError already says so.
+func unwrapCoerceError(err error) error {
+	if err == errIncompatible {
+		return nil
+	}
+	return err
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// timeLayouts are the layouts of the times returned by the API. Times are
+// upper-cased before parsing, as the API may return "2018-02-02t00:00:31z".
+var timeLayouts = []string{time.RFC3339Nano, "2006-01-02T15:04:05.999999999", "2006-01-02"}
+
+// coerce stores value, decoded from JSON, in field, converting it to the
+// field type.
+func coerce(field reflect.Value, value interface{}) error {
+	if value == nil {
+		return nil
+	}
+	switch {
+	case field.Kind() == reflect.Pointer:
+		elem := reflect.New(field.Type().Elem())
+		if err := coerce(elem.Elem(), value); err != nil {
+			return err
+		}
+		field.Set(elem)
+		return nil
+	case field.Kind() == reflect.Interface && field.NumMethod() == 0:
+		field.Set(reflect.ValueOf(value))
+		return nil
+	case field.Type() == timeType:
+		s, ok := value.(string)
+		if !ok {
+			return errIncompatible
+		}
+		var err error
+		for _, layout := range timeLayouts {
+			var t time.Time
+			if t, err = time.Parse(layout, strings.ToUpper(s)); err == nil {
+				field.Set(reflect.ValueOf(t))
+				return nil
+			}
+		}
+		return err
+	}
+	if u, ok := field.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		s, ok := value.(string)
+		if !ok {
+			return errIncompatible
+		}
+		return u.UnmarshalText([]byte(s))
+	}
+	switch field.Kind() {
+	case reflect.String:
+		switch v := value.(type) {
+		case string:
+			field.SetString(v)
+		case float64:
+			field.SetString(strconv.FormatFloat(v, 'f', -1, 64))
+		case bool:
+			field.SetString(strconv.FormatBool(v))
+		default:
+			return errIncompatible
+		}
+	case reflect.Bool:
+		switch v := value.(type) {
+		case bool:
+			field.SetBool(v)
+		case string:
+			b, err := strconv.ParseBool(v)
+			if err != nil {
+				return err
+			}
+			field.SetBool(b)
+		default:
+			return errIncompatible
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		var n int64
+		switch v := value.(type) {
+		case float64:
+			if v != math.Trunc(v) || v < math.MinInt64 || v >= math.MaxInt64 {
+				return errIncompatible
+			}
+			n = int64(v)
+		case string:
+			var err error
+			if n, err = strconv.ParseInt(strings.TrimSpace(v), 10, 64); err != nil {
+				return err
+			}
+		default:
+			return errIncompatible
+		}
+		if field.OverflowInt(n) {
+			return fmt.Errorf("%d overflows %s", n, field.Type())
+		}
+		field.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		var n uint64
+		switch v := value.(type) {
+		case float64:
+			if v != math.Trunc(v) || v < 0 || v >= math.MaxUint64 {
+				return errIncompatible
+			}
+			n = uint64(v)
+		case string:
+			var err error
+			if n, err = strconv.ParseUint(strings.TrimSpace(v), 10, 64); err != nil {
+				return err
+			}
+		default:
+			return errIncompatible
+		}
+		if field.OverflowUint(n) {
+			return fmt.Errorf("%d overflows %s", n, field.Type())
+		}
+		field.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		var f float64
+		switch v := value.(type) {
+		case float64:
+			f = v
+		case string:
+			var err error
+			if f, err = strconv.ParseFloat(strings.TrimSpace(v), 64); err != nil {
+				return err
+			}
+		default:
+			return errIncompatible
+		}
+		if field.OverflowFloat(f) {
+			return fmt.Errorf("%g overflows %s", f, field.Type())
+		}
+		field.SetFloat(f)
+	case reflect.Slice:
+		list, ok := value.([]interface{})
+		if !ok {
+			return errIncompatible
+		}
+		slice := reflect.MakeSlice(field.Type(), len(list), len(list))
+		for i, elem := range list {
+			if err := coerce(slice.Index(i), elem); err != nil {
+				return fmt.Errorf("element %d: %w", i, err)
+			}
+		}
+		field.Set(slice)
+	default:
+		return errIncompatible
+	}
+	return nil
+}
@@ -0,0 +1,158 @@
+package slicingdice
+
+import (
+	"errors"
+	"net"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func typeOf[T any]() reflect.Type {
+	return reflect.TypeOf((*T)(nil)).Elem()
+}
+
+func TestCoerce(t *testing.T) {
+	five := 5
+	tests := []struct {
+		name  string
+		typ   reflect.Type
+		value interface{}
+		want  interface{}
+		err   string // substring of the error, "incompatible" for errIncompatible
+	}{
+		{"string from string", typeOf[string](), "ford ka", "ford ka", ""},
+		{"string from integer", typeOf[string](), 2016.0, "2016", ""},
+		{"string from decimal", typeOf[string](), 10.5, "10.5", ""},
+		{"string from bool", typeOf[string](), true, "true", ""},
+		{"string from list", typeOf[string](), []interface{}{"a"}, nil, "incompatible"},
+
+		{"bool from bool", typeOf[bool](), true, true, ""},
+		{"bool from string", typeOf[bool](), "false", false, ""},
+		{"bool from bad string", typeOf[bool](), "yes", nil, "invalid syntax"},
+		{"bool from number", typeOf[bool](), 1.0, nil, "incompatible"},
+
+		{"int from number", typeOf[int](), 2016.0, 2016, ""},
+		{"int from string", typeOf[int](), " 2016 ", 2016, ""},
+		{"int from negative string", typeOf[int64](), "-3", int64(-3), ""},
+		{"int from decimal", typeOf[int](), 1.5, nil, "incompatible"},
+		{"int from huge number", typeOf[int64](), 1e20, nil, "incompatible"},
+		{"int from bad string", typeOf[int](), "20x6", nil, "invalid syntax"},
+		{"int8 overflow", typeOf[int8](), 300.0, nil, "300 overflows int8"},
+		{"int8 overflow from string", typeOf[int8](), "-300", nil, "-300 overflows int8"},
+		{"int from bool", typeOf[int](), true, nil, "incompatible"},
+
+		{"uint from number", typeOf[uint](), 3.0, uint(3), ""},
+		{"uint from string", typeOf[uint16](), "7", uint16(7), ""},
+		{"uint from negative", typeOf[uint](), -1.0, nil, "incompatible"},
+		{"uint from negative string", typeOf[uint](), "-1", nil, "invalid syntax"},
+		{"uint8 overflow", typeOf[uint8](), "300", nil, "300 overflows uint8"},
+
+		{"float from number", typeOf[float64](), 10.5, 10.5, ""},
+		{"float from string", typeOf[float64](), "10.5", 10.5, ""},
+		{"float32 from number", typeOf[float32](), 1.5, float32(1.5), ""},
+		{"float32 overflow", typeOf[float32](), 1e40, nil, "overflows float32"},
+		{"float from bad string", typeOf[float64](), "ten", nil, "invalid syntax"},
+		{"float from bool", typeOf[float64](), false, nil, "incompatible"},
+
+		{"time RFC 3339", typeOf[time.Time](), "2018-02-02T00:00:31Z", time.Date(2018, 2, 2, 0, 0, 31, 0, time.UTC), ""},
+		{"time lower-case", typeOf[time.Time](), "2018-02-02t00:00:31z", time.Date(2018, 2, 2, 0, 0, 31, 0, time.UTC), ""},
+		{"time with offset", typeOf[time.Time](), "2016-01-01T03:00:00.5+03:00", time.Date(2016, 1, 1, 0, 0, 0, 5e8, time.UTC), ""},
+		{"time without zone", typeOf[time.Time](), "2018-02-02T00:00:31", time.Date(2018, 2, 2, 0, 0, 31, 0, time.UTC), ""},
+		{"date", typeOf[time.Time](), "2016-01-01", time.Date(2016, 1, 1, 0, 0, 0, 0, time.UTC), ""},
+		{"time from bad string", typeOf[time.Time](), "yesterday", nil, "cannot parse"},
+		{"time from number", typeOf[time.Time](), 1.0, nil, "incompatible"},
+
+		{"pointer", typeOf[*int](), "5", &five, ""},
+		{"pointer error", typeOf[*int](), "x", nil, "invalid syntax"},
+		{"interface", typeOf[interface{}](), map[string]interface{}{"x": 1.0}, map[string]interface{}{"x": 1.0}, ""},
+
+		{"slice", typeOf[[]string](), []interface{}{"a", "b"}, []string{"a", "b"}, ""},
+		{"slice of converted values", typeOf[[]int](), []interface{}{"1", 2.0}, []int{1, 2}, ""},
+		{"slice from string", typeOf[[]int](), "1", nil, "incompatible"},
+		{"slice element error", typeOf[[]int](), []interface{}{1.0, "x"}, nil, "element 1: "},
+
+		{"text unmarshaler", typeOf[net.IP](), "10.0.0.1", net.ParseIP("10.0.0.1"), ""},
+		{"text unmarshaler error", typeOf[net.IP](), "10.0.0", nil, "invalid IP address"},
+		{"text unmarshaler from number", typeOf[net.IP](), 10.0, nil, "incompatible"},
+		{"struct", typeOf[struct{ A int }](), map[string]interface{}{"A": 1.0}, nil, "incompatible"},
+	}
+	for _, tt := range tests {
+		field := reflect.New(tt.typ).Elem()
+		err := coerce(field, tt.value)
+		switch {
+		case tt.err == "incompatible":
+			if err != errIncompatible {
+				t.Errorf("%s: error = %v, want %v", tt.name, err, errIncompatible)
+			}
+		case tt.err != "":
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("%s: error = %v, want %q", tt.name, err, tt.err)
+			}
+		case err != nil:
+			t.Errorf("%s: %v", tt.name, err)
+		default:
+			got := field.Interface()
+			if want, ok := tt.want.(time.Time); ok {
+				if !got.(time.Time).Equal(want) {
+					t.Errorf("%s: got %v, want %v", tt.name, got, want)
+				}
+			} else if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("%s: got %#v, want %#v", tt.name, got, tt.want)
+			}
+		}
+	}
+}
+
+func TestDecodeRows(t *testing.T) {
+	type car struct {
+		ID      string `sd:"entity-id"`
+		Year    int    `sd:"year"`
+		Sold    bool   `sd:"sold"`
+		Skipped string `sd:"-"`
+		Note    string
+	}
+	cars, err := DecodeRows[car](Rows{
+		{"entity-id": "c1", "year": "2016", "sold": "true", "Skipped": "x", "Note": "x"},
+		{"entity-id": "c2", "year": nil},
+	})
+	want := []car{{ID: "c1", Year: 2016, Sold: true}, {ID: "c2"}}
+	if err != nil || !reflect.DeepEqual(cars, want) {
+		t.Errorf("DecodeRows() = %+v, %v, want %+v", cars, err, want)
+	}
+	if _, err := DecodeRows[int](nil); err == nil {
+		t.Error("DecodeRows[int]() succeeded, want an error")
+	}
+
+	tests := []struct {
+		name  string
+		rows  Rows
+		want  DecodeError
+		cause error
+	}{
+		{"incompatible", Rows{{"year": 2016.0}, {"sold": 1.0}},
+			DecodeError{Row: 1, Column: "sold", Field: "Sold", Value: 1.0, Type: typeOf[bool]()}, nil},
+		{"parse error", Rows{{"year": "20x6"}},
+			DecodeError{Row: 0, Column: "year", Field: "Year", Value: "20x6", Type: typeOf[int]()}, strconv.ErrSyntax},
+	}
+	for _, tt := range tests {
+		_, err := DecodeRows[car](tt.rows)
+		var got *DecodeError
+		if !errors.As(err, &got) {
+			t.Errorf("%s: error = %v, want a *DecodeError", tt.name, err)
+			continue
+		}
+		if got.Row != tt.want.Row || got.Column != tt.want.Column || got.Field != tt.want.Field ||
+			got.Value != tt.want.Value || got.Type != tt.want.Type {
+			t.Errorf("%s: got %+v, want %+v", tt.name, got, tt.want)
+		}
+		if tt.cause == nil && got.Err != nil || tt.cause != nil && !errors.Is(err, tt.cause) {
+			t.Errorf("%s: cause = %v, want %v", tt.name, got.Err, tt.cause)
+		}
+		if !strings.HasPrefix(err.Error(), "decoding row ") || !strings.Contains(err.Error(), "field "+tt.want.Field) {
+			t.Errorf("%s: message %q", tt.name, err)
+		}
+	}
+}
@@ -0,0 +1,302 @@
+// Package sdconfig loads the settings of a SlicingDice client from a YAML
+// or TOML file with named profiles and from environment variables.
+//
+// A file holds one profile per environment; the profile used is the one
+// given to Load, else the one named by SD_PROFILE, else the file
+// default-profile, else "default":
+//
+//	default-profile: dev
+//	profiles:
+//	  dev:
+//	    base-url: http://localhost:8080/v1
+//	    keys:
+//	      master-key: DEV_MASTER_KEY
+//	  prod:
+//	    keys-file: /etc/slicingdice/keys.json
+//	    timeout: 30s
+//	    retry:
+//	      max-attempts: 5
+//	      initial-backoff: 500ms
+//	    tls:
+//	      ca-file: /etc/ssl/slicingdice-ca.pem
+//	      min-version: "1.3"
+//
+// Environment variables override the file: SD_API_ADDRESS sets the base
+// URL, SD_READ_KEY, SD_WRITE_KEY, SD_MASTER_KEY and SD_CUSTOM_KEY set the
+// keys, replacing the keys-file of the profile, and SD_TIMEOUT sets the timeout, either a duration such as "30s" or
+// a number of seconds. The settings are turned into client options:
+//
+//	cfg, err := sdconfig.Load("slicingdice.yaml", "")
+//	if err != nil {
+//		return err
+//	}
+//	opts, err := cfg.Options()
+//	if err != nil {
+//		return err
+//	}
+//	client := slicingdice.NewClient(opts...)
+package sdconfig
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/SlicingDice/slicingdice-go/slicingdice"
+	"gopkg.in/yaml.v3"
+)
+
+// Config holds the settings of a client.
+type Config struct {
+	Profile  string                   // name of the profile loaded, empty if no file was read
+	BaseURL  string                   // address of the API, empty for the client default
+	Keys     slicingdice.APIKey       // keys used when KeysFile is empty
+	KeysFile string                   // JSON file read by slicingdice.FileCredentials
+	Timeout  time.Duration            // timeout of each request, zero for the client default
+	Retry    *slicingdice.RetryPolicy // retry policy, nil for the client default
+	TLS      TLS
+}
+
+// TLS holds the TLS settings of a client.
+type TLS struct {
+	CAFile             string // PEM file of the CAs trusted instead of the system ones
+	CertFile           string // PEM file of the client certificate
+	KeyFile            string // PEM file of the client certificate key
+	MinVersion         string // minimum TLS version, "1.2" or "1.3"
+	InsecureSkipVerify bool   // skip certificate verification, for testing only
+}
+
+type file struct {
+	DefaultProfile string             `yaml:"default-profile" toml:"default-profile"`
+	Profiles       map[string]profile `yaml:"profiles" toml:"profiles"`
+}
+
+type profile struct {
+	BaseURL  string    `yaml:"base-url" toml:"base-url"`
+	Keys     keys      `yaml:"keys" toml:"keys"`
+	KeysFile string    `yaml:"keys-file" toml:"keys-file"`
+	Timeout  *duration `yaml:"timeout" toml:"timeout"`
+	Retry    *retry    `yaml:"retry" toml:"retry"`
+	TLS      tlsConfig `yaml:"tls" toml:"tls"`
+}
+
+type keys struct {
+	WriteKey  string `yaml:"write-key" toml:"write-key"`
+	ReadKey   string `yaml:"read-key" toml:"read-key"`
+	MasterKey string `yaml:"master-key" toml:"master-key"`
+	CustomKey string `yaml:"custom-key" toml:"custom-key"`
+}
+
+type retry struct {
+	MaxAttempts    *int      `yaml:"max-attempts" toml:"max-attempts"`
+	InitialBackoff *duration `yaml:"initial-backoff" toml:"initial-backoff"`
+	MaxBackoff     *duration `yaml:"max-backoff" toml:"max-backoff"`
+	Jitter         *float64  `yaml:"jitter" toml:"jitter"`
+	RetryMutations *bool     `yaml:"retry-mutations" toml:"retry-mutations"`
+}
+
+type tlsConfig struct {
+	CAFile             string `yaml:"ca-file" toml:"ca-file"`
+	CertFile           string `yaml:"cert-file" toml:"cert-file"`
+	KeyFile            string `yaml:"key-file" toml:"key-file"`
+	MinVersion         string `yaml:"min-version" toml:"min-version"`
+	InsecureSkipVerify bool   `yaml:"insecure-skip-verify" toml:"insecure-skip-verify"`
+}
+
+// duration is a time.Duration written as a string such as "30s".
+type duration time.Duration
+
+func (d *duration) UnmarshalText(text []byte) error {
+	v, err := parseDuration(string(text))
+	*d = duration(v)
+	return err
+}
+
+// parseDuration parses a duration such as "30s" or a number of seconds.
+func parseDuration(s string) (time.Duration, error) {
+	if seconds, err := strconv.Atoi(s); err == nil {
+		return time.Duration(seconds) * time.Second, nil
+	}
+	return time.ParseDuration(s)
+}
+
+// Load reads the profile named profile from the YAML or TOML file at path,
+// chosen by its extension (.yaml, .yml or .toml), and applies the
+// environment variables over it. An empty path reads the file named by
+// SD_CONFIG, if any, so the settings may come from the environment alone.
+func Load(path string, profile string) (*Config, error) {
+	if path == "" {
+		path = os.Getenv("SD_CONFIG")
+	}
+	cfg := new(Config)
+	if path != "" {
+		if err := cfg.load(path, profile); err != nil {
+			return nil, err
+		}
+	}
+	if err := cfg.applyEnv(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// load reads the profile named name from the file at path.
+func (c *Config) load(path string, name string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("config: %w", err)
+	}
+	var f file
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &f)
+	case ".toml":
+		err = toml.Unmarshal(data, &f)
+	default:
+		return fmt.Errorf("config: %s: unknown file format, use .yaml, .yml or .toml", path)
+	}
+	if err != nil {
+		return fmt.Errorf("config: parsing %s: %w", path, err)
+	}
+	if name == "" {
+		name = os.Getenv("SD_PROFILE")
+	}
+	if name == "" {
+		name = f.DefaultProfile
+	}
+	if name == "" {
+		name = "default"
+	}
+	p, ok := f.Profiles[name]
+	if !ok {
+		return fmt.Errorf("config: %s: profile %q not found", path, name)
+	}
+	c.Profile = name
+	c.BaseURL = p.BaseURL
+	c.Keys = slicingdice.APIKey(p.Keys)
+	c.KeysFile = p.KeysFile
+	if p.Timeout != nil {
+		c.Timeout = time.Duration(*p.Timeout)
+	}
+	if p.Retry != nil {
+		policy := slicingdice.DefaultRetryPolicy()
+		if p.Retry.MaxAttempts != nil {
+			policy.MaxAttempts = *p.Retry.MaxAttempts
+		}
+		if p.Retry.InitialBackoff != nil {
+			policy.InitialBackoff = time.Duration(*p.Retry.InitialBackoff)
+		}
+		if p.Retry.MaxBackoff != nil {
+			policy.MaxBackoff = time.Duration(*p.Retry.MaxBackoff)
+		}
+		if p.Retry.Jitter != nil {
+			policy.Jitter = *p.Retry.Jitter
+		}
+		if p.Retry.RetryMutations != nil {
+			policy.RetryMutations = *p.Retry.RetryMutations
+		}
+		c.Retry = &policy
+	}
+	c.TLS = TLS(p.TLS)
+	return nil
+}
+
+// applyEnv overrides the settings set by environment variables.
+func (c *Config) applyEnv() error {
+	if v := os.Getenv("SD_API_ADDRESS"); v != "" {
+		c.BaseURL = v
+	}
+	for _, env := range []struct {
+		name string
+		key  *string
+	}{
+		{"SD_READ_KEY", &c.Keys.ReadKey},
+		{"SD_WRITE_KEY", &c.Keys.WriteKey},
+		{"SD_MASTER_KEY", &c.Keys.MasterKey},
+		{"SD_CUSTOM_KEY", &c.Keys.CustomKey},
+	} {
+		if v := os.Getenv(env.name); v != "" {
+			*env.key = v
+			// Keys from the environment replace the keys file.
+			c.KeysFile = ""
+		}
+	}
+	if v := os.Getenv("SD_TIMEOUT"); v != "" {
+		timeout, err := parseDuration(v)
+		if err != nil {
+			return fmt.Errorf("config: SD_TIMEOUT: %w", err)
+		}
+		c.Timeout = timeout
+	}
+	return nil
+}
+
+// Options returns the client options applying c. Settings left empty add
+// no option, so the keys and timeout given to slicingdice.New are kept
+// unless c sets them. It fails if a TLS file cannot be read.
+func (c *Config) Options() ([]slicingdice.Option, error) {
+	var opts []slicingdice.Option
+	if c.BaseURL != "" {
+		opts = append(opts, slicingdice.WithBaseURL(c.BaseURL))
+	}
+	if c.KeysFile != "" {
+		opts = append(opts, slicingdice.WithCredentialProvider(slicingdice.FileCredentials(c.KeysFile)))
+	} else if c.Keys != (slicingdice.APIKey{}) {
+		keys := c.Keys
+		opts = append(opts, slicingdice.WithAPIKey(&keys))
+	}
+	if c.Timeout != 0 {
+		opts = append(opts, slicingdice.WithTimeout(c.Timeout))
+	}
+	if c.Retry != nil {
+		opts = append(opts, slicingdice.WithRetryPolicy(*c.Retry))
+	}
+	tlsOpts, err := c.TLS.options()
+	if err != nil {
+		return nil, err
+	}
+	return append(opts, tlsOpts...), nil
+}
+
+// options returns the client options applying t.
+func (t TLS) options() ([]slicingdice.Option, error) {
+	var opts []slicingdice.Option
+	if t.CAFile != "" {
+		pem, err := os.ReadFile(t.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("config: tls: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("config: tls: %s: no certificate found", t.CAFile)
+		}
+		opts = append(opts, slicingdice.WithRootCAs(pool))
+	}
+	if t.CertFile != "" || t.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(t.CertFile, t.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("config: tls: %w", err)
+		}
+		opts = append(opts, slicingdice.WithClientCertificates(cert))
+	}
+	switch t.MinVersion {
+	case "":
+	case "1.2":
+		opts = append(opts, slicingdice.WithMinTLSVersion(tls.VersionTLS12))
+	case "1.3":
+		opts = append(opts, slicingdice.WithMinTLSVersion(tls.VersionTLS13))
+	default:
+		return nil, errors.New("config: tls: min-version must be \"1.2\" or \"1.3\"")
+	}
+	if t.InsecureSkipVerify {
+		opts = append(opts, slicingdice.WithInsecureSkipVerifyForTestingOnly())
+	}
+	return opts, nil
+}
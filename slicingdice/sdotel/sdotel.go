@@ -0,0 +1,225 @@
+// Package sdotel traces SlicingDice API calls with OpenTelemetry.
+//
+// It provides an interceptor that creates one client span per call, named
+// after the endpoint, and propagates the trace context through the request
+// headers:
+//
+//	client := slicingdice.New(keys, 60,
+//		slicingdice.WithInterceptors(sdotel.Interceptor()))
+package sdotel
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/SlicingDice/slicingdice-go/slicingdice"
+	"github.com/SlicingDice/slicingdice-go/slicingdice/sdquery"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentationName identifies this package as the tracer of the spans.
+const instrumentationName = "github.com/SlicingDice/slicingdice-go/slicingdice/sdotel"
+
+// Attribute keys set on every span.
+const (
+	EndpointKey   = attribute.Key("slicingdice.endpoint")
+	DimensionKey  = attribute.Key("slicingdice.dimension")
+	QueryNamesKey = attribute.Key("slicingdice.query_names")
+	TookKey       = attribute.Key("slicingdice.took")
+	AttemptsKey   = attribute.Key("slicingdice.attempts")
+	MethodKey     = attribute.Key("http.request.method")
+	StatusCodeKey = attribute.Key("http.response.status_code")
+	ErrorTypeKey  = attribute.Key("error.type")
+)
+
+// endpointNames maps endpoints to the names of their constants, which are
+// used as span names.
+var endpointNames = map[string]string{
+	slicingdice.RESULT:             "RESULT",
+	slicingdice.SCORE:              "SCORE",
+	slicingdice.INSERT:             "INSERT",
+	slicingdice.COLUMN:             "COLUMN",
+	slicingdice.DATABASE:           "DATABASE",
+	slicingdice.TOP_VALUES:         "TOP_VALUES",
+	slicingdice.EXISTS_ENTITY:      "EXISTS_ENTITY",
+	slicingdice.COUNT_ENTITY:       "COUNT_ENTITY",
+	slicingdice.COUNT_ENTITY_TOTAL: "COUNT_ENTITY_TOTAL",
+	slicingdice.COUNT_EVENT:        "COUNT_EVENT",
+	slicingdice.AGGREGATION:        "AGGREGATION",
+	slicingdice.SAVED:              "SAVED",
+	slicingdice.SQL:                "SQL",
+	slicingdice.DELETE:             "DELETE",
+	slicingdice.UPDATE:             "UPDATE",
+}
+
+type config struct {
+	tracerProvider trace.TracerProvider
+	propagator     propagation.TextMapPropagator
+}
+
+// Option configures the interceptor returned by Interceptor.
+type Option func(*config)
+
+// WithTracerProvider sets the provider of the tracer that creates the
+// spans. The global provider is used by default.
+func WithTracerProvider(provider trace.TracerProvider) Option {
+	return func(c *config) {
+		c.tracerProvider = provider
+	}
+}
+
+// WithPropagator sets the propagator that injects the trace context into
+// the request headers. The global propagator is used by default.
+func WithPropagator(propagator propagation.TextMapPropagator) Option {
+	return func(c *config) {
+		c.propagator = propagator
+	}
+}
+
+// Interceptor returns a slicingdice.Interceptor that wraps every call in a
+// client span.
+func Interceptor(opts ...Option) slicingdice.Interceptor {
+	c := config{
+		tracerProvider: otel.GetTracerProvider(),
+		propagator:     otel.GetTextMapPropagator(),
+	}
+	for _, opt := range opts {
+		opt(&c)
+	}
+	tracer := c.tracerProvider.Tracer(instrumentationName)
+
+	return func(ctx context.Context, call *slicingdice.Call, next slicingdice.Handler) (map[string]interface{}, error) {
+		attrs := []attribute.KeyValue{
+			EndpointKey.String(call.Path),
+			MethodKey.String(call.Method),
+		}
+		if dimension := dimensionOf(call.Query); dimension != "" {
+			attrs = append(attrs, DimensionKey.String(dimension))
+		}
+		if names := queryNames(call); len(names) != 0 {
+			attrs = append(attrs, QueryNamesKey.StringSlice(names))
+		}
+		ctx, span := tracer.Start(ctx, spanName(call.Endpoint),
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(attrs...))
+		defer span.End()
+
+		if call.Header == nil {
+			call.Header = http.Header{}
+		}
+		c.propagator.Inject(ctx, propagation.HeaderCarrier(call.Header))
+
+		result, err := next(ctx, call)
+
+		span.SetAttributes(AttemptsKey.Int(call.Attempts))
+		if call.StatusCode != 0 {
+			span.SetAttributes(StatusCodeKey.Int(call.StatusCode))
+		}
+		if took, ok := result["took"].(float64); ok {
+			span.SetAttributes(TookKey.Float64(took))
+		}
+		if err != nil {
+			span.SetAttributes(ErrorTypeKey.String(classify(call, err)))
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		return result, err
+	}
+}
+
+func spanName(endpoint string) string {
+	if name, ok := endpointNames[endpoint]; ok {
+		return "SlicingDice " + name
+	}
+	return "SlicingDice " + endpoint
+}
+
+// classify returns a short, low-cardinality description of why a call
+// failed.
+func classify(call *slicingdice.Call, err error) string {
+	switch {
+	case errors.Is(err, context.Canceled):
+		return "canceled"
+	case errors.Is(err, slicingdice.ErrTimeout), errors.Is(err, context.DeadlineExceeded):
+		return "timeout"
+	case errors.Is(err, slicingdice.ErrAuth):
+		return "auth"
+	case errors.Is(err, slicingdice.ErrRateLimit):
+		return "rate_limit"
+	case errors.Is(err, slicingdice.ErrUnknownColumn):
+		return "unknown_column"
+	case errors.Is(err, slicingdice.ErrInvalidQuery):
+		return "invalid_query"
+	case call.Attempts == 0:
+		// The call was rejected before being sent, e.g. by a validator.
+		return "client"
+	case call.StatusCode == 0:
+		return "transport"
+	case call.StatusCode >= 500:
+		return "server"
+	}
+	return "api_" + strconv.Itoa(call.StatusCode)
+}
+
+// dimensionOf returns the dimension a query targets, if it names one.
+func dimensionOf(query interface{}) string {
+	if q, ok := objectOf(query); ok {
+		dimension, _ := q["dimension"].(string)
+		return dimension
+	}
+	return ""
+}
+
+// queryNames returns the names of the queries sent in a call: the
+// "query-name" of count queries, or the keys of a top values query.
+func queryNames(call *slicingdice.Call) []string {
+	var names []string
+	if q, ok := objectOf(call.Query); ok {
+		if call.Endpoint == slicingdice.TOP_VALUES {
+			for name := range q {
+				names = append(names, name)
+			}
+		} else if name, ok := q["query-name"].(string); ok {
+			names = append(names, name)
+		}
+		return names
+	}
+	var items []interface{}
+	switch q := call.Query.(type) {
+	case []interface{}:
+		items = q
+	case []map[string]interface{}:
+		for _, m := range q {
+			items = append(items, m)
+		}
+	case []sdquery.Query:
+		for _, m := range q {
+			items = append(items, m)
+		}
+	}
+	for _, item := range items {
+		if m, ok := objectOf(item); ok {
+			if name, ok := m["query-name"].(string); ok {
+				names = append(names, name)
+			}
+		}
+	}
+	return names
+}
+
+// objectOf returns a query written as a map or built with sdquery.
+func objectOf(query interface{}) (map[string]interface{}, bool) {
+	switch q := query.(type) {
+	case map[string]interface{}:
+		return q, q != nil
+	case sdquery.Query:
+		return q, q != nil
+	}
+	return nil, false
+}
@@ -0,0 +1,220 @@
+// Package sdprom exports Prometheus metrics about SlicingDice API calls.
+//
+// A Collector is registered with a Prometheus registry and plugged into a
+// client as an interceptor:
+//
+//	collector := sdprom.NewCollector()
+//	prometheus.MustRegister(collector)
+//	client := slicingdice.New(keys, 60,
+//		slicingdice.WithInterceptors(collector.Interceptor()))
+//
+// Metrics are labelled by endpoint path, e.g. "/query/count/entity/". Request
+// counts are also labelled by the HTTP status of the response and error
+// counts by the SlicingDice API error code (see slicingdice.SDError). When
+// there is none, the code label is one of "canceled", "timeout", "transport"
+// and "client".
+package sdprom
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/SlicingDice/slicingdice-go/slicingdice"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Collector collects metrics about the calls made by the clients it
+// intercepts. It implements prometheus.Collector.
+type Collector struct {
+	requests     *prometheus.CounterVec
+	errors       *prometheus.CounterVec
+	retries      *prometheus.CounterVec
+	duration     *prometheus.HistogramVec
+	took         *prometheus.HistogramVec
+	requestSize  *prometheus.HistogramVec
+	responseSize *prometheus.HistogramVec
+}
+
+type config struct {
+	namespace       string
+	constLabels     prometheus.Labels
+	durationBuckets []float64
+	sizeBuckets     []float64
+}
+
+// Option configures a Collector.
+type Option func(*config)
+
+// WithNamespace sets the prefix of the metric names, "slicingdice" by
+// default.
+func WithNamespace(namespace string) Option {
+	return func(c *config) {
+		c.namespace = namespace
+	}
+}
+
+// WithConstLabels sets labels added to every metric, e.g. to tell apart
+// several clients registered in the same registry.
+func WithConstLabels(labels prometheus.Labels) Option {
+	return func(c *config) {
+		c.constLabels = labels
+	}
+}
+
+// WithDurationBuckets sets the buckets, in seconds, of the wall-clock and
+// server-reported latency histograms.
+func WithDurationBuckets(buckets []float64) Option {
+	return func(c *config) {
+		c.durationBuckets = buckets
+	}
+}
+
+// WithSizeBuckets sets the buckets, in bytes, of the payload size
+// histograms.
+func WithSizeBuckets(buckets []float64) Option {
+	return func(c *config) {
+		c.sizeBuckets = buckets
+	}
+}
+
+// NewCollector returns a Collector configured by opts.
+func NewCollector(opts ...Option) *Collector {
+	c := config{
+		namespace:       "slicingdice",
+		durationBuckets: prometheus.DefBuckets,
+		sizeBuckets:     prometheus.ExponentialBuckets(64, 4, 10),
+	}
+	for _, opt := range opts {
+		opt(&c)
+	}
+	return &Collector{
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace:   c.namespace,
+			Name:        "requests_total",
+			Help:        "Number of calls made to the SlicingDice API.",
+			ConstLabels: c.constLabels,
+		}, []string{"endpoint", "code"}),
+		errors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace:   c.namespace,
+			Name:        "errors_total",
+			Help:        "Number of calls to the SlicingDice API that failed.",
+			ConstLabels: c.constLabels,
+		}, []string{"endpoint", "code"}),
+		retries: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace:   c.namespace,
+			Name:        "retries_total",
+			Help:        "Number of requests to the SlicingDice API sent again after a failure.",
+			ConstLabels: c.constLabels,
+		}, []string{"endpoint"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace:   c.namespace,
+			Name:        "request_duration_seconds",
+			Help:        "Wall-clock duration of calls to the SlicingDice API, including retries.",
+			ConstLabels: c.constLabels,
+			Buckets:     c.durationBuckets,
+		}, []string{"endpoint"}),
+		took: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace:   c.namespace,
+			Name:        "server_took_seconds",
+			Help:        "Processing time reported by the SlicingDice API in the took field.",
+			ConstLabels: c.constLabels,
+			Buckets:     c.durationBuckets,
+		}, []string{"endpoint"}),
+		requestSize: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace:   c.namespace,
+			Name:        "request_size_bytes",
+			Help:        "Size of the bodies sent to the SlicingDice API.",
+			ConstLabels: c.constLabels,
+			Buckets:     c.sizeBuckets,
+		}, []string{"endpoint"}),
+		responseSize: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace:   c.namespace,
+			Name:        "response_size_bytes",
+			Help:        "Size of the bodies received from the SlicingDice API.",
+			ConstLabels: c.constLabels,
+			Buckets:     c.sizeBuckets,
+		}, []string{"endpoint"}),
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	c.requests.Describe(ch)
+	c.errors.Describe(ch)
+	c.retries.Describe(ch)
+	c.duration.Describe(ch)
+	c.took.Describe(ch)
+	c.requestSize.Describe(ch)
+	c.responseSize.Describe(ch)
+}
+
+// Collect implements prometheus.Collector.
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	c.requests.Collect(ch)
+	c.errors.Collect(ch)
+	c.retries.Collect(ch)
+	c.duration.Collect(ch)
+	c.took.Collect(ch)
+	c.requestSize.Collect(ch)
+	c.responseSize.Collect(ch)
+}
+
+// Interceptor returns a slicingdice.Interceptor that records every call in
+// the collector. Calls rejected before being sent, e.g. by a validator, are
+// only counted in the request and error totals.
+func (c *Collector) Interceptor() slicingdice.Interceptor {
+	return func(ctx context.Context, call *slicingdice.Call, next slicingdice.Handler) (map[string]interface{}, error) {
+		start := time.Now()
+		result, err := next(ctx, call)
+		elapsed := time.Since(start)
+
+		endpoint := call.Endpoint
+		c.requests.WithLabelValues(endpoint, statusOf(call, err)).Inc()
+		if err != nil {
+			c.errors.WithLabelValues(endpoint, errorCodeOf(call, err)).Inc()
+		}
+		if call.Attempts == 0 {
+			return result, err
+		}
+		if call.Attempts > 1 {
+			c.retries.WithLabelValues(endpoint).Add(float64(call.Attempts - 1))
+		}
+		c.duration.WithLabelValues(endpoint).Observe(elapsed.Seconds())
+		if took, ok := result["took"].(float64); ok {
+			c.took.WithLabelValues(endpoint).Observe(took)
+		}
+		c.requestSize.WithLabelValues(endpoint).Observe(float64(call.RequestSize))
+		if call.StatusCode != 0 {
+			c.responseSize.WithLabelValues(endpoint).Observe(float64(call.ResponseSize))
+		}
+		return result, err
+	}
+}
+
+// errorCodeOf returns the value of the code label of the error count: the API
+// error code if there is one.
+func errorCodeOf(call *slicingdice.Call, err error) string {
+	var sdErr *slicingdice.SDError
+	if errors.As(err, &sdErr) && sdErr.Code != 0 {
+		return strconv.Itoa(sdErr.Code)
+	}
+	return statusOf(call, err)
+}
+
+// statusOf returns the value of the code label of the request count: the
+// HTTP status of the response if there is one.
+func statusOf(call *slicingdice.Call, err error) string {
+	switch {
+	case errors.Is(err, context.Canceled):
+		return "canceled"
+	case errors.Is(err, context.DeadlineExceeded):
+		return "timeout"
+	case call.Attempts == 0:
+		return "client"
+	case call.StatusCode == 0:
+		return "transport"
+	}
+	return strconv.Itoa(call.StatusCode)
+}
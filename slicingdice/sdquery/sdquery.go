@@ -0,0 +1,269 @@
+// Package sdquery builds SlicingDice queries with typed predicates instead of
+// hand-written nested maps.
+//
+// Conditions on columns are combined with And, Or and Not into a filter,
+// and the filter is placed in a query for CountEntity, CountEvent, Result,
+// Score, Delete or Update:
+//
+//	filter := sdquery.Or(
+//		sdquery.Equals("car-model", "toyota corolla"),
+//		sdquery.Equals("car-model", "honda fit"),
+//	)
+//	client.CountEntity([]sdquery.Query{
+//		sdquery.Count("corolla-or-fit", filter),
+//		sdquery.Count("ford-ka", sdquery.Equals("car-model", "ford ka")),
+//	})
+//
+// Queries are plain maps holding the exact JSON expected by the API, so
+// the client validates and sends them as if they were written by hand.
+package sdquery
+
+import "time"
+
+// Expr is a filter expression: a condition on a column, a frequency group
+// or a boolean composition of expressions.
+type Expr interface {
+	// value returns the expression as it appears in the JSON of a query.
+	value() interface{}
+}
+
+// Condition is a predicate on a column, e.g. {"car-model": {"equals": "ford ka"}}.
+// Several operators may apply to the same column, as on event columns
+// filtered by value, period and frequency.
+type Condition struct {
+	column    string
+	operators map[string]interface{}
+}
+
+func newCondition(column string, operator string, value interface{}) *Condition {
+	return &Condition{column: column, operators: map[string]interface{}{operator: jsonValue(value)}}
+}
+
+// Equals matches the entities whose column equals value.
+func Equals(column string, value interface{}) *Condition {
+	return newCondition(column, "equals", value)
+}
+
+// NotEquals matches the entities whose column differs from value.
+func NotEquals(column string, value interface{}) *Condition {
+	return newCondition(column, "not-equals", value)
+}
+
+// Gt matches the entities whose column is greater than value.
+func Gt(column string, value interface{}) *Condition {
+	return newCondition(column, "gt", value)
+}
+
+// Gte matches the entities whose column is greater than or equal to value.
+func Gte(column string, value interface{}) *Condition {
+	return newCondition(column, "gte", value)
+}
+
+// Lt matches the entities whose column is less than value.
+func Lt(column string, value interface{}) *Condition {
+	return newCondition(column, "lt", value)
+}
+
+// Lte matches the entities whose column is less than or equal to value.
+func Lte(column string, value interface{}) *Condition {
+	return newCondition(column, "lte", value)
+}
+
+// Range matches the entities whose column is between from and to.
+func Range(column string, from interface{}, to interface{}) *Condition {
+	return newCondition(column, "range", []interface{}{jsonValue(from), jsonValue(to)})
+}
+
+// Between returns a copy of c restricted to the events that happened
+// between from and to. c is left unchanged.
+func (c *Condition) Between(from time.Time, to time.Time) *Condition {
+	return c.with("between", []interface{}{jsonValue(from), jsonValue(to)})
+}
+
+// Minfreq returns a copy of c restricted to the entities with at least n
+// matching events. c is left unchanged.
+func (c *Condition) Minfreq(n int) *Condition {
+	return c.with("minfreq", n)
+}
+
+// with returns a copy of c with operator set to value.
+func (c *Condition) with(operator string, value interface{}) *Condition {
+	operators := c.copyOperators()
+	operators[operator] = value
+	return &Condition{column: c.column, operators: operators}
+}
+
+func (c *Condition) copyOperators() map[string]interface{} {
+	operators := make(map[string]interface{}, len(c.operators)+1)
+	for operator, value := range c.operators {
+		operators[operator] = value
+	}
+	return operators
+}
+
+func (c *Condition) value() interface{} {
+	return map[string]interface{}{c.column: c.copyOperators()}
+}
+
+// jsonValue returns value as written in a query. Times are written in
+// RFC 3339 format, in UTC.
+func jsonValue(value interface{}) interface{} {
+	if t, ok := value.(time.Time); ok {
+		return t.UTC().Format(time.RFC3339)
+	}
+	return value
+}
+
+// freqgroup is a frequency group.
+type freqgroup struct {
+	minfreq    int
+	conditions []*Condition
+}
+
+// Freqgroup matches the entities with at least minfreq events matching any
+// of conditions, counted together.
+func Freqgroup(minfreq int, conditions ...*Condition) Expr {
+	return &freqgroup{minfreq: minfreq, conditions: conditions}
+}
+
+func (f *freqgroup) value() interface{} {
+	conditions := make([]interface{}, len(f.conditions))
+	for i, c := range f.conditions {
+		conditions[i] = c.value()
+	}
+	return map[string]interface{}{"minfreq": f.minfreq, "freqgroup": conditions}
+}
+
+// boolean joins expressions with an operator, e.g. [a, "and", b].
+type boolean struct {
+	operator string
+	exprs    []Expr
+}
+
+// And matches the entities matching every expression.
+func And(exprs ...Expr) Expr {
+	return &boolean{operator: "and", exprs: exprs}
+}
+
+// Or matches the entities matching any expression.
+func Or(exprs ...Expr) Expr {
+	return &boolean{operator: "or", exprs: exprs}
+}
+
+func (b *boolean) value() interface{} {
+	list := make([]interface{}, 0, 2*len(b.exprs))
+	for i, e := range b.exprs {
+		if i > 0 {
+			list = append(list, b.operator)
+		}
+		list = append(list, e.value())
+	}
+	return list
+}
+
+// not negates an expression.
+type not struct {
+	expr Expr
+}
+
+// Not matches the entities not matching expr.
+func Not(expr Expr) Expr {
+	return &not{expr: expr}
+}
+
+func (n *not) value() interface{} {
+	return []interface{}{"not", n.expr.value()}
+}
+
+// group encloses an expression in a list.
+type group struct {
+	expr Expr
+}
+
+// Group encloses expr in its own list. And and Or already group their
+// expressions when nested, so Group is only needed to reproduce a query
+// written by hand, e.g. [["not", ...]].
+func Group(expr Expr) Expr {
+	return &group{expr: expr}
+}
+
+func (g *group) value() interface{} {
+	return []interface{}{g.expr.value()}
+}
+
+// Filter returns expr as the value of the "query" field of a query: the
+// list built by And or Or, or a list holding any other expression.
+func Filter(expr Expr) []interface{} {
+	if b, ok := expr.(*boolean); ok {
+		return b.value().([]interface{})
+	}
+	return []interface{}{expr.value()}
+}
+
+// Query is a query as sent to the client methods.
+type Query map[string]interface{}
+
+// Count returns a query named name, counting the entities or events
+// matching filter. It is sent, alone or in a list, to CountEntity or
+// CountEvent.
+func Count(name string, filter Expr) Query {
+	return Query{"query-name": name, "query": Filter(filter)}
+}
+
+// Extract returns a query returning the given columns of the entities
+// matching filter. It is sent to Result or Score.
+func Extract(filter Expr, columns ...string) Query {
+	q := Query{"query": Filter(filter)}
+	if len(columns) != 0 {
+		q["columns"] = columns
+	}
+	return q
+}
+
+// Delete returns a query deleting the entities matching filter. It is sent
+// to Delete.
+func Delete(filter Expr) Query {
+	return Query{"query": Filter(filter)}
+}
+
+// Update returns a query setting the columns in set to their values on the
+// entities matching filter. It is sent to Update.
+func Update(filter Expr, set map[string]interface{}) Query {
+	values := make(map[string]interface{}, len(set))
+	for column, value := range set {
+		values[column] = jsonValue(value)
+	}
+	return Query{"query": Filter(filter), "set": values}
+}
+
+// Name sets the "query-name" field of q.
+func (q Query) Name(name string) Query {
+	q["query-name"] = name
+	return q
+}
+
+// Dimension sets the dimension q applies to.
+func (q Query) Dimension(dimension string) Query {
+	q["dimension"] = dimension
+	return q
+}
+
+// BypassCache sets whether q skips the API cache.
+func (q Query) BypassCache(bypass bool) Query {
+	q["bypass-cache"] = bypass
+	return q
+}
+
+// Limit sets the maximum number of entities returned by q.
+func (q Query) Limit(limit int) Query {
+	q["limit"] = limit
+	return q
+}
+
+// OrderBy appends column to the sort order of q, with direction "asc" or
+// "desc".
+func (q Query) OrderBy(column string, direction string) Query {
+	order, _ := q["order"].([]interface{})
+	q["order"] = append(order, map[string]interface{}{column: direction})
+	return q
+}
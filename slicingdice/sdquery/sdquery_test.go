@@ -0,0 +1,144 @@
+package sdquery
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+// The fixtures are the queries of tests_and_examples/examples. Between
+// writes RFC 3339 times, so the periods of the frequency group fixture,
+// written there as plain dates, are given in that format.
+func TestMarshal(t *testing.T) {
+	from := time.Date(2016, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2016, 1, 7, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name  string
+		query Query
+		want  string
+	}{
+		{
+			"count_entity.json and",
+			Count("test_result_query", And(
+				Equals("string-test-column", "value:matched_value"),
+				Equals("integer-test-column", 1000001),
+				Equals("boolean-test-column", "true"),
+				Equals("datetime-test-column", time.Date(2018, 1, 21, 0, 50, 0, 0, time.UTC)),
+			)),
+			`{"query-name": "test_result_query", "query": [{"string-test-column": {"equals": "value:matched_value"}}, "and", {"integer-test-column": {"equals": 1000001}}, "and", {"boolean-test-column": {"equals": "true"}}, "and", {"datetime-test-column": {"equals": "2018-01-21T00:50:00Z"}}]}`,
+		},
+		{
+			"count_entity.json or",
+			Count("test_result_query", Or(
+				Equals("string-test-column", "value:matched_value"),
+				Equals("decimal-test-column", 1000001.1),
+				Equals("boolean-test-column", "true"),
+			)),
+			`{"query-name": "test_result_query", "query": [{"string-test-column": {"equals": "value:matched_value"}}, "or", {"decimal-test-column": {"equals": 1000001.1}}, "or", {"boolean-test-column": {"equals": "true"}}]}`,
+		},
+		{
+			"count_entity.json not-equals",
+			Count("test_result_query", NotEquals("integer-test-column", 1000001)),
+			`{"query-name": "test_result_query", "query": [{"integer-test-column": {"not-equals": 1000001}}]}`,
+		},
+		{
+			"count_entity.json not and group",
+			Count("test_result_query", Or(
+				Or(Equals("string-test-column", "matched_value"), Not(Equals("integer-test-column", 1000001))),
+				Group(Not(Equals("boolean-test-column", "false"))),
+			)),
+			`{"query-name": "test_result_query", "query": [[{"string-test-column": {"equals": "matched_value"}}, "or", ["not", {"integer-test-column": {"equals": 1000001}}]], "or", [["not", {"boolean-test-column": {"equals": "false"}}]]]}`,
+		},
+		{
+			"count_entity.json freqgroup",
+			Count("test_result_query", And(
+				And(
+					Freqgroup(3,
+						Equals("event-string-test-column", "value:matched_value").Between(from, to),
+						Equals("event-string-test-column-2", "value:matched_value").Between(from, to),
+					),
+					Equals("integer-test-column", 1000001),
+				),
+				Group(Equals("boolean-test-column", "true")),
+			)),
+			`{"query-name": "test_result_query", "query": [[{"minfreq": 3, "freqgroup": [{"event-string-test-column": {"equals": "value:matched_value", "between": ["2016-01-01T00:00:00Z", "2016-01-07T00:00:00Z"]}}, {"event-string-test-column-2": {"equals": "value:matched_value", "between": ["2016-01-01T00:00:00Z", "2016-01-07T00:00:00Z"]}}]}, "and", {"integer-test-column": {"equals": 1000001}}], "and", [{"boolean-test-column": {"equals": "true"}}]]}`,
+		},
+		{
+			"count_entity.json between and minfreq",
+			Count("test_result_query", And(
+				Equals("event-string-test-column", "value:matched_value").Minfreq(1).Between(from, to),
+				Equals("integer-test-column", 1000001),
+			)),
+			`{"query-name": "test_result_query", "query": [{"event-string-test-column": {"minfreq": 1, "equals": "value:matched_value", "between": ["2016-01-01T00:00:00Z", "2016-01-07T00:00:00Z"]}}, "and", {"integer-test-column": {"equals": 1000001}}]}`,
+		},
+		{
+			"count_event.json between",
+			Count("test_result_query", Equals("event-string-test-column", "value:matched_value").Between(from, to)),
+			`{"query-name": "test_result_query", "query": [{"event-string-test-column": {"equals": "value:matched_value", "between": ["2016-01-01T00:00:00Z", "2016-01-07T00:00:00Z"]}}]}`,
+		},
+		{
+			"result.json",
+			Extract(NotEquals("date-test-column", "2016-01-01"), "entity-id", "date-test-column").OrderBy("entity-id", "asc"),
+			`{"query": [{"date-test-column": {"not-equals": "2016-01-01"}}], "columns": ["entity-id", "date-test-column"], "order": [{"entity-id": "asc"}]}`,
+		},
+		{
+			"delete.json",
+			Delete(Equals("string-test-column", "value:deleted_entity")).Name("deleted_query").Dimension("delete-test"),
+			`{"query-name": "deleted_query", "query": [{"string-test-column": {"equals": "value:deleted_entity"}}], "dimension": "delete-test"}`,
+		},
+		{
+			"update.json",
+			Update(And(
+				Equals("string-test-column", "value:garbage_value"),
+				Equals("integer-test-column", 1000002),
+			), map[string]interface{}{
+				"string-test-column":   "value:another_garbage_value",
+				"boolean-test-column":  "false",
+				"integer-test-column":  3000003,
+				"datetime-test-column": time.Date(2018, 1, 24, 10, 50, 0, 0, time.UTC),
+			}).Dimension("delete-test"),
+			`{"query": [{"string-test-column": {"equals": "value:garbage_value"}}, "and", {"integer-test-column": {"equals": 1000002}}], "set": {"string-test-column": "value:another_garbage_value", "boolean-test-column": "false", "integer-test-column": 3000003, "datetime-test-column": "2018-01-24T10:50:00Z"}, "dimension": "delete-test"}`,
+		},
+	}
+	for _, tt := range tests {
+		if got, want := marshalCanonical(t, tt.query), canonical(t, tt.want); got != want {
+			t.Errorf("%s:\n got %s\nwant %s", tt.name, got, want)
+		}
+	}
+}
+
+func TestConditionCopies(t *testing.T) {
+	from := time.Date(2016, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2016, 1, 7, 0, 0, 0, 0, time.UTC)
+	base := Equals("event-string-test-column", "value:matched_value")
+	base.Between(from, to)
+	base.Minfreq(2)
+	want := canonical(t, `{"query-name": "q", "query": [{"event-string-test-column": {"equals": "value:matched_value"}}]}`)
+	if got := marshalCanonical(t, Count("q", base)); got != want {
+		t.Errorf("base condition changed:\n got %s\nwant %s", got, want)
+	}
+}
+
+// marshalCanonical returns the JSON of value with its object keys sorted.
+func marshalCanonical(t *testing.T, value interface{}) string {
+	t.Helper()
+	data, err := json.Marshal(value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return canonical(t, string(data))
+}
+
+// canonical returns the JSON document data with its object keys sorted.
+func canonical(t *testing.T, data string) string {
+	t.Helper()
+	var value interface{}
+	if err := json.Unmarshal([]byte(data), &value); err != nil {
+		t.Fatal(err)
+	}
+	sorted, err := json.Marshal(value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(sorted)
+}
@@ -3,19 +3,25 @@ package slicingdice
 
 import (
 	"bytes"
-	"crypto/tls"
+	"context"
 	"encoding/json"
 	"errors"
+	"io"
 	"io/ioutil"
+	"log/slog"
 	"net/http"
 	"os"
 	"time"
-	"reflect"
-	"fmt"
 	"strings"
 )
 
-var sd_base = os.Getenv("SD_API_ADDRESS")
+// DEFAULT_BASE_URL is the address used when neither WithBaseURL nor the
+// SD_API_ADDRESS environment variable set one.
+const DEFAULT_BASE_URL = "https://api.slicingdice.com/v1"
+
+// DEFAULT_USER_AGENT is sent in the User-Agent header unless WithUserAgent
+// sets another one.
+const DEFAULT_USER_AGENT = "slicingdice-go/2.1.0"
 
 // All these constants representing all endpoints available in SlicingDice API.
 const (
@@ -37,10 +43,10 @@ const (
 )
 
 /* APIKey is used to access the keys that we insert in the SlicingDice API.
-There is only one rule: if you put the master key, you do not put the other,
-because already by default the client uses for everything. Otherwise,
-use a key to writing, if you want to write (insert data, create columns)
-and use a key to reading (make queries).
+Every call uses the least powerful key allowed to perform it: the read key
+for queries, the write key to insert data, create columns, delete and update,
+and the master or custom key for everything else. The master key, or else the
+custom key, is used when the key a call needs is not set.
 */
 type APIKey struct {
 	WriteKey  string
@@ -52,9 +58,21 @@ type APIKey struct {
 // SlicingDice is the main structure of slicingdice. Through it, we will make queries,
 // we will create columns, we'll take databases, etc.
 type SlicingDice struct {
-	key     map[string]string
-	timeout int
-	Test    bool
+	credentials  CredentialProvider
+	timeout      time.Duration
+	Test         bool // sandbox mode, configured by WithTestMode
+	baseURL      string
+	userAgent    string
+	logger       *slog.Logger
+	redaction    Redaction
+	httpClient   *http.Client
+	roundTripper http.RoundTripper
+	transport    transportConfig
+	retry        RetryPolicy
+	interceptors []Interceptor
+	testMode     TestMode
+	dryRun       *DryRunLog
+	dump         *httpDump
 }
 
 // stringInSlice checks if a array has a item.
@@ -67,256 +85,225 @@ func stringInSlice(a string, list []string) bool {
 	return false
 }
 
-// hasValidSavedQuery checks whether the query passed by user is valid. It
-// validates especially if the query type is valid.
-func hasValidSavedQuery(query interface{}) error {
-	queryConverted := query.(map[string]interface{})
-	listQueryTypes := []string{"count/entity", "count/event", "count/entity/total", "aggregation", "top_values"}
-	if !stringInSlice(queryConverted["type"].(string), listQueryTypes) {
-		return errors.New("Saved Query Validator: this dictionary don't have query type valid.")
+// NewClient returns a new SlicingDice object configured by opts, which are
+// applied in order. The base URL defaults to the SD_API_ADDRESS environment
+// variable, read when the client is created, or DEFAULT_BASE_URL.
+func NewClient(opts ...Option) *SlicingDice {
+	SlicingDice := new(SlicingDice)
+	SlicingDice.baseURL = os.Getenv("SD_API_ADDRESS")
+	if len(SlicingDice.baseURL) == 0 {
+		SlicingDice.baseURL = DEFAULT_BASE_URL
 	}
-	return nil
-}
-
-// hasValidCountQuery checks whether the count query passed by user is valid. It
-// validates especially if the query len is less than 10.
-func hasValidCountQuery(query interface{}) error {
-	switch query.(type) {
-	case []interface{}:
-		querySize := len(query.([]interface{}))
-		if querySize > 10 {
-			return errors.New("Count Query Validator: the query count entity has a limit of 10 queries by request.")
-		}
+	SlicingDice.userAgent = DEFAULT_USER_AGENT
+	SlicingDice.transport = defaultTransportConfig()
+	for _, opt := range opts {
+		opt(SlicingDice)
 	}
-
-	return nil
+	SlicingDice.httpClient = SlicingDice.newHTTPClient()
+	return SlicingDice
 }
 
-// hasValidTopValuesQuery checks whether the top values query passed by user is valid. It
-// validates especially if the query len is less than 5 and column len is less than 6.
-func hasValidTopValuesQuery(query interface{}) error {
-	queryConverted := query.(map[string]interface{})
-	// check query limit
-	if len(queryConverted) > 5 {
-		return errors.New("Top Values Validator: the top values query has a limit of 5 queries by request.")
-	}
-	// check column limit
-	for _, value := range queryConverted {
-		if len(value.(map[string]interface{})) > 6 {
-			return errors.New("Top Values Validator: the query exceeds the limit of columns per query in request")
-		}
-	}
-	return nil
+// New returns a new SlicingDice object using key and a timeout in seconds.
+// The options are applied after them, as in NewClient.
+func New(key *APIKey, timeout int, opts ...Option) *SlicingDice {
+	opts = append([]Option{
+		WithAPIKey(key),
+		WithTimeout(time.Duration(timeout) * time.Second),
+	}, opts...)
+	return NewClient(opts...)
 }
 
-// hasValidDataExtractionQuery checks whether the data extraction(result and score)
-// query passed by user is valid. It validates especially if the 'limit' key
-// has a len less than 100 and if has a valid column.
-func hasValidDataExtractionQuery(query interface{}) error {
-	queryConverted := query.(map[string]interface{})
-	if val, ok := queryConverted["columns"]; ok {
-		columns := reflect.ValueOf(val)
-		if columns.Len() > 10 {
-			return errors.New("Data Extraction Validator: The key 'columns' in data extraction result must have up to 10 columns.")
+// getFullUrl joins the client base URL, or the test mode one if set in test
+// mode, and path.
+func (s *SlicingDice) getFullUrl(path string) string {
+	baseURL := s.baseURL
+	if s.Test && len(s.testMode.BaseURL) != 0 {
+		baseURL = s.testMode.BaseURL
+	}
+	return strings.TrimSuffix(baseURL, "/") + path
+}
+
+// Call describes a single call to the SlicingDice API. Interceptors receive
+// it before the request is sent and may change it.
+type Call struct {
+	Endpoint string      // one of the endpoint constants, e.g. COUNT_ENTITY
+	Path     string      // Endpoint plus any suffix, e.g. a saved query name
+	Method   string      // HTTP method
+	KeyLevel int         // permission level the API key must have, e.g. READ_KEY_LEVEL
+	Query    interface{} // query as given to the client method; a string for SQL
+	Header   http.Header // extra headers sent with the request
+
+	// Set once the request has been sent.
+	StatusCode   int // HTTP status of the last response, zero if none
+	Attempts     int // number of requests sent, including retries
+	RequestSize  int // size in bytes of the request body
+	ResponseSize int // size in bytes of the last response body
+}
+
+// idempotent reports whether the call can safely be sent more than once.
+// Reads and queries are idempotent, calls that change data are not.
+func (c *Call) idempotent() bool {
+	if c.Method == "GET" {
+		return true
+	}
+	switch c.Endpoint {
+	case INSERT, COLUMN, SAVED, DELETE, UPDATE:
+		return false
+	case SQL:
+		statement, _ := c.Query.(string)
+		return sqlReadOnly(statement)
+	}
+	return true
+}
+
+// makeRequest passes the call through the client interceptors before
+// sending it. In test mode, calls are refused if the test mode is not
+// configured, and destructive calls unless allowed.
+func (s *SlicingDice) makeRequest(ctx context.Context, c *Call) (map[string]interface{}, error) {
+	if c.Path == "" {
+		c.Path = c.Endpoint
+	}
+	c.KeyLevel = keyLevelOf(c)
+	if s.Test {
+		if err := s.prepareTestCall(c); err != nil {
+			return nil, err
 		}
 	}
-	return nil
-}
-
-// hasValidColumn checks whether the new column is valid. Checks type, name,
-// description; enumerate, decimal-place and string types.
-func hasValidColumn(query interface{}) error {
-	if reflect.ValueOf(query).Kind() == reflect.Slice {
-		columnData := query.([]interface{})
-		for _, column := range columnData {
-			column := column.(map[string]interface{})
-	        validateColumn(column)
-	    }
-	} else {
-		query := query.(map[string]interface{})
-		validateColumn(query)
-	}
-	return nil
+	return s.chain()(ctx, c)
 }
 
-func validateColumn(query map[string]interface{}) error {
-	validTypeColumns := []string{
-		"unique-id", "boolean", "string", "integer", "decimal",
-		"enumerated", "date", "integer-event",
-		"decimal-event", "string-event", "datetime",
+// sendCall checks request method, convert the query passed for use to JSON
+// and executes the request, retrying it according to the client retry policy.
+// The request is bound to ctx, so cancelling ctx or reaching its deadline
+// aborts the request and returns an error wrapping context.Canceled or
+// context.DeadlineExceeded.
+func (s *SlicingDice) sendCall(ctx context.Context, c *Call) (result map[string]interface{}, err error) {
+	if s.logger != nil {
+		start := time.Now()
+		defer func() {
+			s.logCall(ctx, c, time.Since(start), err)
+		}()
 	}
-	// validate name
-	if _, ok := query["name"]; !ok {
-		return errors.New("Column Validator: the column should have a name.")
-	}
-	name := query["name"]
-	if len(name.(string)) > 80 {
-		return errors.New("Column Validator: the column's name have a very big name.(Max: 80 chars)")
-	}
-	// validate description
-	if _, ok := query["description"]; ok {
-		description := query["description"]
-		if len(description.(string)) > 300 {
-			return errors.New("Column Validator: the column's description have a very big name.(Max: 300chars)")
-		}
-	}
-	// validate type column
-	if _, ok := query["type"]; !ok {
-		return errors.New("Column Validator: the column should have a type.")
-	}
-	typeColumn := query["type"].(string)
-	if !stringInSlice(typeColumn, validTypeColumns) {
-		return errors.New("Column Validator: this column have a invalid type.")
-	}
-	// validate decimal place key
-	if _, ok := query["decimal-place"]; ok {
-		decimalTypes := []string{"decimal", "decimal-event"}
-		if !stringInSlice(query["type"].(string), decimalTypes) {
-			return errors.New("Column Validator: this column is only accepted on type 'decimal' or 'decimal-event'.")
-		}
-	}
-	// validate string column type
-	if query["type"] == "string" {
-		if _, ok := query["cardinality"]; !ok {
-			return errors.New("Column Validator: the column with type string should have 'cardinality' key.")
-		}
-		cardinalityTypes := []string{"high", "low"}
-		if !stringInSlice(query["cardinality"].(string), cardinalityTypes) {
-			return errors.New("Column Validator: the column 'cardinality' has invalid value.")
-		}
+	methodsAllowed := []string{"GET", "POST", "PUT", "DELETE"}
+	if !stringInSlice(c.Method, methodsAllowed) {
+		return nil, errors.New("request: this is a invalid method to make request.")
 	}
-	// validate enumerated column
-	if query["type"] == "enumerated" {
-		if _, ok := query["range"]; !ok {
-			return errors.New("Column Validator: the 'enumerate' type needs of the 'range' parameter.")
+	var key string
+	if s.dryRun == nil {
+		if key, err = s.keyFor(ctx, c); err != nil {
+			return nil, err
 		}
 	}
-	return nil
-}
+	url := s.getFullUrl(c.Path)
 
-// New returns a new SlicingDice object.
-func New(key *APIKey, timeout int) *SlicingDice {
-	SlicingDice := new(SlicingDice)
-	if len(key.MasterKey) != 0 {
-		SlicingDice.key = map[string]string{
-			"masterKey": key.MasterKey,
-		}
-	} else if len(key.CustomKey) != 0 {
-		SlicingDice.key = map[string]string{
-			"customKey": key.CustomKey,
+	var body []byte
+	var contentType string
+	if c.Endpoint == SQL {
+		statement, ok := c.Query.(string)
+		if !ok {
+			return nil, errors.New("request: the SQL query should be a string.")
 		}
+		contentType = "application/sql"
+		body = []byte(statement)
 	} else {
-		SlicingDice.key = map[string]string{
-			"readKey":  key.ReadKey,
-			"writeKey": key.WriteKey,
+		contentType = "application/json"
+		queryData := new(bytes.Buffer)
+		if err := json.NewEncoder(queryData).Encode(c.Query); err != nil {
+			return nil, err
 		}
+		body = queryData.Bytes()
 	}
-	SlicingDice.timeout = timeout
-	return SlicingDice
-}
-
-func (s *SlicingDice) getKeyLevel(keys map[string]string) int {
-	if len(keys["masterKey"]) != 0 || len(keys["customKey"]) != 0 {
-		return 2
-	} else if len(keys["writeKey"]) != 0 {
-		return 1
-	} else if len(keys["readKey"]) != 0 {
-		return 0
+	c.RequestSize = len(body)
+	s.logPayload(ctx, c)
+	if s.dryRun != nil {
+		return s.dryRunResponse(c, url, contentType, body)
 	}
-	return -1
-}
 
-// getKey returns of smart way a key to be used in the request SlicingDice API.
-func (s *SlicingDice) getKey(keys map[string]string, endpointKeyLevel int) (string, error) {
-	currentKeyLevel := s.getKeyLevel(keys)
-	if currentKeyLevel == 2 {
-		if len(keys["masterKey"]) != 0 {
-			return keys["masterKey"], nil
-		} else if len(keys["customKey"]) != 0 {
-			return keys["customKey"], nil
+	attempts := 1
+	if c.idempotent() || s.retry.RetryMutations {
+		attempts = s.retry.MaxAttempts
+	}
+	for attempt := 1; ; attempt++ {
+		c.Attempts = attempt
+		start := time.Now()
+		result, retryAfter, err := s.send(ctx, c, url, key, contentType, body)
+		s.logAttempt(ctx, c, time.Since(start), err)
+		if err == nil || attempt >= attempts || !isRetryable(ctx, err) {
+			return result, err
 		}
-	} else if currentKeyLevel != endpointKeyLevel {
-		return "", errors.New("API key: This key don't have permission to peform this operation")
-	} else {
-		if len(keys["writeKey"]) != 0 {
-			return keys["writeKey"], nil
+		delay, ok := s.retry.backoff(attempt, retryAfter)
+		if !ok {
+			return result, err
 		}
-		if len(keys["readKey"]) != 0 {
-			return keys["readKey"], nil
+		if s.logger != nil {
+			s.logger.DebugContext(ctx, "slicingdice: retrying request",
+				"endpoint", c.Endpoint, "attempt", attempt, "delay", delay)
+		}
+		if err := sleepContext(ctx, delay); err != nil {
+			return nil, contextError(c.Method, url, err)
 		}
 	}
-	return "", nil
-}
-
-// getFullUrl checks if enviroment has the SD_API_ADDRESS variable. If don't has
-// him define the url base how 'https://api.slicingdice.com/v1'.
-func (s *SlicingDice) getFullUrl(path string) string {
-	if len(sd_base) != 0 {
-		return sd_base + path
-	} 
-	return "https://api.slicingdice.com/v1" + path
-}
-
-func (s *SlicingDice) makeRequest(url string, method string, endpointKeyLevel int, query interface{}) (map[string]interface{}, error) {
-	return s.makeRequestSQL(url, method, endpointKeyLevel, query, false)
 }
 
-// makeRequest checks request method, convert the query passed for use to JSON
-// and executes the request.
-func (s *SlicingDice) makeRequestSQL(url string, method string, endpointKeyLevel int, query interface{}, sql bool) (map[string]interface{}, error) {
-	methodsAllowed := []string{"GET", "POST", "PUT", "DELETE"}
-	if !stringInSlice(method, methodsAllowed) {
-		return nil, errors.New("request: this is a invalid method to make request.")
+// send makes a single attempt of a request. Besides the decoded response it
+// returns the delay asked by the server in the Retry-After header, if any.
+func (s *SlicingDice) send(ctx context.Context, c *Call, url string, key string, contentType string, body []byte) (map[string]interface{}, time.Duration, error) {
+	if s.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.timeout)
+		defer cancel()
 	}
-	key, err := s.getKey(s.key, endpointKeyLevel)
+	request, err := http.NewRequestWithContext(ctx, c.Method, url, bytes.NewReader(body))
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
-	timeout := time.Duration(time.Duration(s.timeout) * time.Second)
-	tr := &http.Transport{
-		TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
-	}
-	client := &http.Client{
-		Timeout:   timeout,
-		Transport: tr,
-	}
-
-
-	var request *http.Request
-	var err_request error
-	var contentType string
-	if sql {
-		contentType = "application/sql"
-		queryData := []byte(query.(string))
-		request, err_request = http.NewRequest(method, url, bytes.NewBuffer(queryData))
-	} else {
-		contentType = "application/json"
-		queryData := new(bytes.Buffer)
-		json.NewEncoder(queryData).Encode(query)
-		request, err_request = http.NewRequest(method, url, queryData)
-	}
-
-	if err_request != nil {
-		return nil, err_request
-	}
-
 	request.Header.Add("Authorization", key)
 	request.Header.Add("Content-Type", contentType)
-	res, err := client.Do(request)
-	return s.handlerResponse(res, err)
+	request.Header.Set("User-Agent", s.userAgent)
+	for name, values := range c.Header {
+		request.Header[name] = values
+	}
+
+	res, err := s.httpClient.Do(request)
+	var retryAfter time.Duration
+	var dumped *bytes.Buffer
+	c.StatusCode, c.ResponseSize = 0, 0
+	if res != nil {
+		c.StatusCode = res.StatusCode
+		if s.dump != nil {
+			dumped = new(bytes.Buffer)
+			res.Body = &teeBody{Reader: io.TeeReader(res.Body, dumped), Closer: res.Body}
+		}
+		res.Body = &countingBody{ReadCloser: res.Body, n: &c.ResponseSize}
+		retryAfter = parseRetryAfter(res.Header.Get("Retry-After"), time.Now())
+	}
+	sendErr := err
+	result, err := s.handlerResponse(res, err)
+	if s.dump != nil {
+		var resBody []byte
+		if dumped != nil {
+			resBody = dumped.Bytes()
+		}
+		s.dump.write(request, body, res, resBody, sendErr)
+	}
+	if err != nil && ctx.Err() != nil {
+		return nil, 0, contextError(c.Method, url, ctx.Err())
+	}
+	return result, retryAfter, err
 }
 
-type SDError struct {
-	message string
-	moreInfo interface{}
-	code int
+// countingBody counts the bytes read from a response body.
+type countingBody struct {
+	io.ReadCloser
+	n *int
 }
 
-func (e *SDError) Error() string {
-	return fmt.Sprintf("Error Code: %d, Message: %s, More Info: %s", e.code, e.message, e.moreInfo)
+func (b *countingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	*b.n += n
+	return n, err
 }
 
-
 // handlerResponse search errors in the response of the request, both the
 // status code as in the API JSON response.
 func (s *SlicingDice) handlerResponse(res *http.Response, err error) (map[string]interface{}, error) {
@@ -325,33 +312,29 @@ func (s *SlicingDice) handlerResponse(res *http.Response, err error) (map[string
 	}
 	// check api response json
 	defer res.Body.Close()
-	contents, _ := ioutil.ReadAll(res.Body)
+	contents, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
 	result := string(contents)
 	decodeResponse, err := s.DecodeJSON(result)
 	if err != nil {
-		return nil, &SDError{"Response parsing error", result, res.StatusCode}
+		return nil, &SDError{Message: "Response parsing error", MoreInfo: result, StatusCode: res.StatusCode}
 	}
 
 	responseDecode, ok := decodeResponse.(map[string]interface{})
 
 	if !ok || len(responseDecode) == 0 {
-		return nil, &SDError{"Response parsing error", result, res.StatusCode}
+		return nil, &SDError{Message: "Response parsing error", MoreInfo: result, StatusCode: res.StatusCode}
 	}
 
 	if val, ok := responseDecode["errors"]; ok {
-		contentErrors := val.([]interface{})[0].(map[string]interface{})
-		var moreInfo interface{}
-
-		if (contentErrors["more-info"] != nil) {
-			moreInfo = contentErrors["more-info"]
-		} else {
-			moreInfo = nil
+		if err := newAPIError(val, res.StatusCode); err != nil {
+			return nil, err
 		}
-
-		return nil, &SDError{contentErrors["message"].(string), moreInfo, res.StatusCode}
 	}
 	if res.StatusCode >= 400 {
-		return nil, &SDError{"Unknown Error", result, res.StatusCode}
+		return nil, &SDError{Message: "Unknown Error", MoreInfo: result, StatusCode: res.StatusCode}
 	}
 	return responseDecode, nil
 }
@@ -368,70 +351,112 @@ func (s *SlicingDice) DecodeJSON(jsonData string) (interface{}, error) {
 // GetDatabase gets information about the current SlicingDice database
 // It returns a JSON converted in map[string]interface{}
 func (s *SlicingDice) GetDatabase() (map[string]interface{}, error) {
-	url := s.getFullUrl(DATABASE)
-	return s.makeRequest(url, "GET", 2, nil)
+	return s.GetDatabaseContext(context.Background())
+}
+
+// GetDatabaseContext is like GetDatabase but binds the request to ctx.
+func (s *SlicingDice) GetDatabaseContext(ctx context.Context) (map[string]interface{}, error) {
+	return s.makeRequest(ctx, &Call{Endpoint: DATABASE, Method: "GET"})
 }
 
 // GetColumns get columns stored in your SlicingDice account
 // It returns a JSON converted in map[string]interface{}
 func (s *SlicingDice) GetColumns() (map[string]interface{}, error) {
-	url := s.getFullUrl(COLUMN)
-	return s.makeRequest(url, "GET", 2, nil)
+	return s.GetColumnsContext(context.Background())
+}
+
+// GetColumnsContext is like GetColumns but binds the request to ctx.
+func (s *SlicingDice) GetColumnsContext(ctx context.Context) (map[string]interface{}, error) {
+	return s.makeRequest(ctx, &Call{Endpoint: COLUMN, Method: "GET"})
 }
 
 // GetSavedQuery get a saved query by name
 // It returns a JSON converted in map[string]interface{}
 func (s *SlicingDice) GetSavedQuery(queryName string) (map[string]interface{}, error) {
-	url := s.getFullUrl(SAVED + queryName)
-	return s.makeRequest(url, "GET", 0, nil)
+	return s.GetSavedQueryContext(context.Background(), queryName)
+}
+
+// GetSavedQueryContext is like GetSavedQuery but binds the request to ctx.
+func (s *SlicingDice) GetSavedQueryContext(ctx context.Context, queryName string) (map[string]interface{}, error) {
+	return s.makeRequest(ctx, &Call{Endpoint: SAVED, Path: SAVED + queryName, Method: "GET"})
 }
 
 // DeleteSavedQuery delete a saved query by name
 // It returns a JSON converted in map[string]interface{}
 func (s *SlicingDice) DeleteSavedQuery(queryName string) (map[string]interface{}, error) {
-	url := s.getFullUrl(SAVED + queryName)
-	return s.makeRequest(url, "DELETE", 2, nil)
+	return s.DeleteSavedQueryContext(context.Background(), queryName)
+}
+
+// DeleteSavedQueryContext is like DeleteSavedQuery but binds the request to ctx.
+func (s *SlicingDice) DeleteSavedQueryContext(ctx context.Context, queryName string) (map[string]interface{}, error) {
+	return s.makeRequest(ctx, &Call{Endpoint: SAVED, Path: SAVED + queryName, Method: "DELETE"})
 }
 
 // GetSavedQueries get all saved queryName
 // It returns a JSON converted in map[string]interface{}
 func (s *SlicingDice) GetSavedQueries() (map[string]interface{}, error) {
-	url := s.getFullUrl(SAVED)
-	return s.makeRequest(url, "GET", 2, nil)
+	return s.GetSavedQueriesContext(context.Background())
+}
+
+// GetSavedQueriesContext is like GetSavedQueries but binds the request to ctx.
+func (s *SlicingDice) GetSavedQueriesContext(ctx context.Context) (map[string]interface{}, error) {
+	return s.makeRequest(ctx, &Call{Endpoint: SAVED, Method: "GET"})
 }
 
 // Inserts data in a SlicingDice database.
 // It returns a JSON converted in map[string]interface{}
 func (s *SlicingDice) Insert(query map[string]interface{}) (map[string]interface{}, error) {
-	url := s.getFullUrl(INSERT)
-	return s.makeRequest(url, "POST", 1, query)
+	return s.InsertContext(context.Background(), query)
+}
+
+// InsertContext is like Insert but binds the request to ctx.
+func (s *SlicingDice) InsertContext(ctx context.Context, query map[string]interface{}) (map[string]interface{}, error) {
+	return s.makeRequest(ctx, &Call{Endpoint: INSERT, Method: "POST", Query: query})
 }
 
 // CreateColumn create a column in SlicingDice
+// The query is a column object, a Column, or a list of them.
 // It returns a JSON converted in map[string]interface{}
 func (s *SlicingDice) CreateColumn(query interface{}) (map[string]interface{}, error) {
-	url := s.getFullUrl(COLUMN)
+	return s.CreateColumnContext(context.Background(), query)
+}
+
+// CreateColumnContext is like CreateColumn but binds the request to ctx.
+func (s *SlicingDice) CreateColumnContext(ctx context.Context, query interface{}) (map[string]interface{}, error) {
+	query, err := columnQuery(query)
+	if err != nil {
+		return nil, err
+	}
 	validate := hasValidColumn(query)
 	if validate != nil {
 		return nil, validate
 	}
-	return s.makeRequest(url, "POST", 1, query)
+	return s.makeRequest(ctx, &Call{Endpoint: COLUMN, Method: "POST", Query: query})
 }
 
 // CountEntity makes a count entity query
 // It returns a JSON converted in map[string]interface{}
 func (s *SlicingDice) CountEntity(query interface{}) (map[string]interface{}, error) {
-	url := s.getFullUrl(COUNT_ENTITY)
+	return s.CountEntityContext(context.Background(), query)
+}
+
+// CountEntityContext is like CountEntity but binds the request to ctx.
+func (s *SlicingDice) CountEntityContext(ctx context.Context, query interface{}) (map[string]interface{}, error) {
 	validate := hasValidCountQuery(query)
 	if validate != nil {
 		return nil, validate
 	}
-	return s.makeRequest(url, "POST", 0, query)
+	return s.makeRequest(ctx, &Call{Endpoint: COUNT_ENTITY, Method: "POST", Query: query})
 }
 
 // CountEntityTotal get total of entity query
 // It returns a JSON converted in map[string]interface{}
 func (s *SlicingDice) CountEntityTotal(data ...[]string) (map[string]interface{}, error) {
+	return s.CountEntityTotalContext(context.Background(), data...)
+}
+
+// CountEntityTotalContext is like CountEntityTotal but binds the request to ctx.
+func (s *SlicingDice) CountEntityTotalContext(ctx context.Context, data ...[]string) (map[string]interface{}, error) {
 	dimensions := make(map[string]interface{})
 
 	if (len(data) != 0) {
@@ -440,102 +465,151 @@ func (s *SlicingDice) CountEntityTotal(data ...[]string) (map[string]interface{}
 		}
 	}
 
-	url := s.getFullUrl(COUNT_ENTITY_TOTAL)
-	return s.makeRequest(url, "POST", 0, dimensions)
+	return s.makeRequest(ctx, &Call{Endpoint: COUNT_ENTITY_TOTAL, Method: "POST", Query: dimensions})
 }
 
 // CountEvent makes a count event query
 // It returns a JSON converted in map[string]interface{}
 func (s *SlicingDice) CountEvent(query interface{}) (map[string]interface{}, error) {
-	url := s.getFullUrl(COUNT_EVENT)
+	return s.CountEventContext(context.Background(), query)
+}
+
+// CountEventContext is like CountEvent but binds the request to ctx.
+func (s *SlicingDice) CountEventContext(ctx context.Context, query interface{}) (map[string]interface{}, error) {
 	validate := hasValidCountQuery(query)
 	if validate != nil {
 		return nil, validate
 	}
-	return s.makeRequest(url, "POST", 0, query)
+	return s.makeRequest(ctx, &Call{Endpoint: COUNT_EVENT, Method: "POST", Query: query})
 }
 
 // Aggregation makes a aggregation query
 // It returns a JSON converted in map[string]interface{}
 func (s *SlicingDice) Aggregation(query map[string]interface{}) (map[string]interface{}, error) {
-	url := s.getFullUrl(AGGREGATION)
-	return s.makeRequest(url, "POST", 0, query)
+	return s.AggregationContext(context.Background(), query)
+}
+
+// AggregationContext is like Aggregation but binds the request to ctx.
+func (s *SlicingDice) AggregationContext(ctx context.Context, query map[string]interface{}) (map[string]interface{}, error) {
+	return s.makeRequest(ctx, &Call{Endpoint: AGGREGATION, Method: "POST", Query: query})
 }
 
 // Result makes a data extraction result query
 // It returns a JSON converted in map[string]interface{}
 func (s *SlicingDice) Result(query map[string]interface{}) (map[string]interface{}, error) {
-	url := s.getFullUrl(RESULT)
+	return s.ResultContext(context.Background(), query)
+}
+
+// ResultContext is like Result but binds the request to ctx.
+func (s *SlicingDice) ResultContext(ctx context.Context, query map[string]interface{}) (map[string]interface{}, error) {
 	validate := hasValidDataExtractionQuery(query)
 	if validate != nil {
 		return nil, validate
 	}
-	return s.makeRequest(url, "POST", 0, query)
+	return s.makeRequest(ctx, &Call{Endpoint: RESULT, Method: "POST", Query: query})
 }
 
 // Score makes a data extraction score query
 // It returns a JSON converted in map[string]interface{}
 func (s *SlicingDice) Score(query map[string]interface{}) (map[string]interface{}, error) {
-	url := s.getFullUrl(SCORE)
+	return s.ScoreContext(context.Background(), query)
+}
+
+// ScoreContext is like Score but binds the request to ctx.
+func (s *SlicingDice) ScoreContext(ctx context.Context, query map[string]interface{}) (map[string]interface{}, error) {
 	validate := hasValidDataExtractionQuery(query)
 	if validate != nil {
 		return nil, validate
 	}
-	return s.makeRequest(url, "POST", 0, query)
+	return s.makeRequest(ctx, &Call{Endpoint: SCORE, Method: "POST", Query: query})
 }
 
 // TopValues makes a top values query
 // It returns a JSON converted in map[string]interface{}
 func (s *SlicingDice) TopValues(query map[string]interface{}) (map[string]interface{}, error) {
-	url := s.getFullUrl(TOP_VALUES)
+	return s.TopValuesContext(context.Background(), query)
+}
+
+// TopValuesContext is like TopValues but binds the request to ctx.
+func (s *SlicingDice) TopValuesContext(ctx context.Context, query map[string]interface{}) (map[string]interface{}, error) {
 	validate := hasValidTopValuesQuery(query)
 	if validate != nil {
 		return nil, validate
 	}
-	return s.makeRequest(url, "POST", 0, query)
+	return s.makeRequest(ctx, &Call{Endpoint: TOP_VALUES, Method: "POST", Query: query})
 }
 
 // ExistsEntity makes a exists entity query
 // It returns a JSON converted in map[string]interface{}
 func (s *SlicingDice) ExistsEntity(ids []string, dimension string) (map[string]interface{}, error) {
-	url := s.getFullUrl(EXISTS_ENTITY)
+	return s.ExistsEntityContext(context.Background(), ids, dimension)
+}
+
+// ExistsEntityContext is like ExistsEntity but binds the request to ctx.
+func (s *SlicingDice) ExistsEntityContext(ctx context.Context, ids []string, dimension string) (map[string]interface{}, error) {
 	query := make(map[string]interface{})
 	query["ids"] = ids
 	if dimension != "" {
 		query["dimension"] = dimension
 	}
-	return s.makeRequest(url, "POST", 0, query)
+	return s.makeRequest(ctx, &Call{Endpoint: EXISTS_ENTITY, Method: "POST", Query: query})
 }
 
 // CreateSavedQuery created a saved query in SlicingDice
 // It returns a JSON converted in map[string]interface{}
 func (s *SlicingDice) CreateSavedQuery(query map[string]interface{}) (map[string]interface{}, error) {
-	url := s.getFullUrl(SAVED)
+	return s.CreateSavedQueryContext(context.Background(), query)
+}
+
+// CreateSavedQueryContext is like CreateSavedQuery but binds the request to ctx.
+func (s *SlicingDice) CreateSavedQueryContext(ctx context.Context, query map[string]interface{}) (map[string]interface{}, error) {
 	validate := hasValidSavedQuery(query)
 	if validate != nil {
 		return nil, validate
 	}
-	return s.makeRequest(url, "POST", 2, query)
+	return s.makeRequest(ctx, &Call{Endpoint: SAVED, Method: "POST", Query: query})
 }
 
 // UpdateSavedQuery update a saved query in SlicingDice by name
 // It returns a JSON converted in map[string]interface{}
 func (s *SlicingDice) UpdateSavedQuery(queryName string, query map[string]interface{}) (map[string]interface{}, error) {
-	url := s.getFullUrl(SAVED + queryName)
-	return s.makeRequest(url, "PUT", 2, query)
+	return s.UpdateSavedQueryContext(context.Background(), queryName, query)
+}
+
+// UpdateSavedQueryContext is like UpdateSavedQuery but binds the request to ctx.
+func (s *SlicingDice) UpdateSavedQueryContext(ctx context.Context, queryName string, query map[string]interface{}) (map[string]interface{}, error) {
+	return s.makeRequest(ctx, &Call{Endpoint: SAVED, Path: SAVED + queryName, Method: "PUT", Query: query})
 }
 
+// Sql makes a SQL query
+// It returns a JSON converted in map[string]interface{}
 func (s *SlicingDice) Sql(query string) (map[string]interface{}, error) {
-	url := s.getFullUrl(SQL)
-	return s.makeRequestSQL(url, "POST", 0, query, true)
+	return s.SqlContext(context.Background(), query)
+}
+
+// SqlContext is like Sql but binds the request to ctx.
+func (s *SlicingDice) SqlContext(ctx context.Context, query string) (map[string]interface{}, error) {
+	return s.makeRequest(ctx, &Call{Endpoint: SQL, Method: "POST", Query: query})
 }
 
+// Delete deletes the entities matching the query
+// It returns a JSON converted in map[string]interface{}
 func (s *SlicingDice) Delete(query map[string]interface{}) (map[string]interface{}, error) {
-	url := s.getFullUrl(DELETE)
-	return s.makeRequest(url, "POST", 0, query)
+	return s.DeleteContext(context.Background(), query)
+}
+
+// DeleteContext is like Delete but binds the request to ctx.
+func (s *SlicingDice) DeleteContext(ctx context.Context, query map[string]interface{}) (map[string]interface{}, error) {
+	return s.makeRequest(ctx, &Call{Endpoint: DELETE, Method: "POST", Query: query})
 }
 
+// Update updates the entities matching the query
+// It returns a JSON converted in map[string]interface{}
 func (s *SlicingDice) Update(query map[string]interface{}) (map[string]interface{}, error) {
-	url := s.getFullUrl(UPDATE)
-	return s.makeRequest(url, "POST", 0, query)
-}
\ No newline at end of file
+	return s.UpdateContext(context.Background(), query)
+}
+
+// UpdateContext is like Update but binds the request to ctx.
+func (s *SlicingDice) UpdateContext(ctx context.Context, query map[string]interface{}) (map[string]interface{}, error) {
+	return s.makeRequest(ctx, &Call{Endpoint: UPDATE, Method: "POST", Query: query})
+}
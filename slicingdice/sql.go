@@ -0,0 +1,195 @@
+package slicingdice
+
+import (
+	"strings"
+	"unicode"
+)
+
+// sqlQuoted stands for a string literal or quoted identifier in the tokens
+// returned by sqlTokens.
+const sqlQuoted = "'"
+
+// sqlReadOnly reports whether statement only reads data. Each of its
+// statements, separated by semicolons, must start with SELECT, after
+// opening parentheses, without a SELECT ... INTO, or with WITH, whose
+// common table expressions and main statement must be read-only in turn.
+// Keywords are only looked for at the start of statements, so function
+// names and identifiers such as REPLACE(...) or [delete-test] don't count.
+// Anything it cannot classify, such as an unterminated comment or literal,
+// is not read-only, so callers relying on it fail closed.
+func sqlReadOnly(statement string) bool {
+	tokens, ok := sqlTokens(statement)
+	if !ok {
+		return false
+	}
+	statements := 0
+	for start := 0; start <= len(tokens); {
+		end := start
+		for end < len(tokens) && tokens[end] != ";" {
+			end++
+		}
+		if end > start {
+			if !sqlReadOnlyTokens(tokens[start:end]) {
+				return false
+			}
+			statements++
+		}
+		start = end + 1
+	}
+	return statements > 0
+}
+
+// sqlReadOnlyTokens reports whether the statement made of tokens only reads
+// data.
+func sqlReadOnlyTokens(tokens []string) bool {
+	for len(tokens) > 0 && tokens[0] == "(" {
+		tokens = tokens[1:]
+	}
+	if len(tokens) == 0 {
+		return false
+	}
+	switch tokens[0] {
+	case "SELECT":
+		// SELECT ... INTO creates a table.
+		for _, token := range tokens {
+			if token == "INTO" {
+				return false
+			}
+		}
+		return true
+	case "WITH":
+		return sqlReadOnlyWith(tokens[1:])
+	}
+	return false
+}
+
+// sqlReadOnlyWith reports whether the tokens following WITH, that is the
+// common table expressions and the main statement, only read data.
+func sqlReadOnlyWith(tokens []string) bool {
+	if len(tokens) > 0 && tokens[0] == "RECURSIVE" {
+		tokens = tokens[1:]
+	}
+	for {
+		// name [(columns)] AS [NOT] [MATERIALIZED] (body)
+		if len(tokens) == 0 {
+			return false
+		}
+		tokens = tokens[1:]
+		if len(tokens) > 0 && tokens[0] == "(" {
+			end := sqlClosing(tokens)
+			if end < 0 {
+				return false
+			}
+			tokens = tokens[end+1:]
+		}
+		if len(tokens) == 0 || tokens[0] != "AS" {
+			return false
+		}
+		tokens = tokens[1:]
+		if len(tokens) > 0 && tokens[0] == "NOT" {
+			tokens = tokens[1:]
+		}
+		if len(tokens) > 0 && tokens[0] == "MATERIALIZED" {
+			tokens = tokens[1:]
+		}
+		if len(tokens) == 0 || tokens[0] != "(" {
+			return false
+		}
+		end := sqlClosing(tokens)
+		if end < 0 || !sqlReadOnlyTokens(tokens[1:end]) {
+			return false
+		}
+		tokens = tokens[end+1:]
+		if len(tokens) == 0 || tokens[0] != "," {
+			return sqlReadOnlyTokens(tokens)
+		}
+		tokens = tokens[1:]
+	}
+}
+
+// sqlClosing returns the index of the parenthesis closing tokens[0], or -1
+// if there is none.
+func sqlClosing(tokens []string) int {
+	depth := 0
+	for i, token := range tokens {
+		switch token {
+		case "(":
+			depth++
+		case ")":
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// sqlTokens splits statement into upper-cased words, parentheses, commas
+// and semicolons. Comments are dropped, and string literals and quoted
+// identifiers, including SlicingDice's [bracketed] column names, become
+// sqlQuoted. It reports false for an unterminated comment or quote.
+func sqlTokens(statement string) ([]string, bool) {
+	var tokens []string
+	for i := 0; i < len(statement); {
+		switch c := statement[i]; {
+		case strings.HasPrefix(statement[i:], "--"):
+			end := strings.IndexByte(statement[i:], '\n')
+			if end < 0 {
+				i = len(statement)
+			} else {
+				i += end + 1
+			}
+		case strings.HasPrefix(statement[i:], "/*"):
+			end := strings.Index(statement[i+2:], "*/")
+			if end < 0 {
+				return nil, false
+			}
+			i += 2 + end + 2
+		case c == '\'' || c == '"' || c == '`' || c == '[':
+			closing := c
+			if c == '[' {
+				closing = ']'
+			}
+			end := quotedEnd(statement, i, closing)
+			if end < 0 {
+				return nil, false
+			}
+			tokens = append(tokens, sqlQuoted)
+			i = end
+		case c == '(' || c == ')' || c == ',' || c == ';':
+			tokens = append(tokens, string(c))
+			i++
+		case sqlWordByte(c):
+			start := i
+			for i < len(statement) && sqlWordByte(statement[i]) {
+				i++
+			}
+			tokens = append(tokens, strings.ToUpper(statement[start:i]))
+		default:
+			i++
+		}
+	}
+	return tokens, true
+}
+
+func sqlWordByte(c byte) bool {
+	return c == '_' || unicode.IsLetter(rune(c)) || unicode.IsDigit(rune(c))
+}
+
+// quotedEnd returns the index after the literal or quoted identifier
+// starting at start and ended by closing, where a doubled closing character
+// stands for itself, or -1 if it is not terminated.
+func quotedEnd(statement string, start int, closing byte) int {
+	for i := start + 1; i < len(statement); i++ {
+		if statement[i] != closing {
+			continue
+		}
+		if i+1 < len(statement) && statement[i+1] == closing {
+			i++
+			continue
+		}
+		return i + 1
+	}
+	return -1
+}
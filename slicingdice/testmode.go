@@ -0,0 +1,69 @@
+package slicingdice
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// TEST_HEADER is sent with the value "true" on every request made in test
+// mode, so test traffic can be told apart from production traffic.
+const TEST_HEADER = "X-SlicingDice-Test"
+
+// TestMode configures the sandbox used when the Test field of the client is
+// set. Credentials is required: calls made in test mode without it fail
+// with an error matching ErrTestMode rather than reach the production
+// database.
+type TestMode struct {
+	// BaseURL is the address requests are sent to in test mode. Empty keeps
+	// the client base URL, the test database being then told apart by its
+	// keys.
+	BaseURL string
+	// Credentials provides the keys of the test database.
+	Credentials CredentialProvider
+	// AllowDestructive lets Delete, Update, DeleteSavedQuery and the SQL
+	// statements that are not read-only through. They fail with an error
+	// matching ErrTestMode otherwise.
+	AllowDestructive bool
+}
+
+// WithTestMode turns on test mode, setting the Test field of the client, and
+// configures it with mode.
+func WithTestMode(mode TestMode) Option {
+	return func(s *SlicingDice) {
+		s.Test = true
+		s.testMode = mode
+	}
+}
+
+// destructive reports whether c deletes or overwrites data. Any SQL
+// statement that sqlReadOnly does not classify as a read is destructive.
+func (c *Call) destructive() bool {
+	switch {
+	case c.Endpoint == DELETE, c.Endpoint == UPDATE:
+		return true
+	case c.Endpoint == SAVED && c.Method == "DELETE":
+		return true
+	case c.Endpoint == SQL:
+		statement, _ := c.Query.(string)
+		return !sqlReadOnly(statement)
+	}
+	return false
+}
+
+// prepareTestCall tags c as a test call. It fails if the test mode has no
+// credentials, or if c is destructive and the test mode does not allow it.
+func (s *SlicingDice) prepareTestCall(c *Call) error {
+	if s.testMode.Credentials == nil {
+		return fmt.Errorf("request: %w: set TestMode.Credentials with WithTestMode", ErrTestMode)
+	}
+	if c.destructive() && !s.testMode.AllowDestructive {
+		return fmt.Errorf("request: %w: %s %s is destructive, set TestMode.AllowDestructive to allow it", ErrTestMode, c.Method, c.Endpoint)
+	}
+	header := make(http.Header, len(c.Header)+1)
+	for name, values := range c.Header {
+		header[name] = values
+	}
+	header.Set(TEST_HEADER, "true")
+	c.Header = header
+	return nil
+}
@@ -0,0 +1,102 @@
+package slicingdice
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestDestructive(t *testing.T) {
+	tests := []struct {
+		name string
+		call Call
+		want bool
+	}{
+		{"delete", Call{Endpoint: DELETE, Method: "POST"}, true},
+		{"update", Call{Endpoint: UPDATE, Method: "POST"}, true},
+		{"delete saved query", Call{Endpoint: SAVED, Method: "DELETE"}, true},
+		{"get saved query", Call{Endpoint: SAVED, Method: "GET"}, false},
+		{"insert", Call{Endpoint: INSERT, Method: "POST"}, false},
+		{"count entity", Call{Endpoint: COUNT_ENTITY, Method: "POST"}, false},
+		{"sql select", Call{Endpoint: SQL, Method: "POST", Query: "SELECT * FROM t"}, false},
+		{"sql with", Call{Endpoint: SQL, Method: "POST", Query: "WITH a AS (SELECT 1) SELECT * FROM a"}, false},
+		{"sql parenthesized", Call{Endpoint: SQL, Method: "POST", Query: "(SELECT a FROM t) UNION (SELECT a FROM u)"}, false},
+		{"sql delete", Call{Endpoint: SQL, Method: "POST", Query: " delete from t"}, true},
+		{"sql commented delete", Call{Endpoint: SQL, Method: "POST", Query: "/* x */ DELETE FROM t"}, true},
+		{"sql line commented drop", Call{Endpoint: SQL, Method: "POST", Query: "-- cleanup\nDROP TABLE t"}, true},
+		{"sql with delete", Call{Endpoint: SQL, Method: "POST", Query: "WITH a AS (SELECT 1) DELETE FROM t"}, true},
+		{"sql insert", Call{Endpoint: SQL, Method: "POST", Query: "INSERT INTO t VALUES (1)"}, true},
+		{"sql unknown", Call{Endpoint: SQL, Method: "POST", Query: "VACUUM t"}, true},
+		{"sql empty", Call{Endpoint: SQL, Method: "POST", Query: ""}, true},
+	}
+	for _, tt := range tests {
+		if got := tt.call.destructive(); got != tt.want {
+			t.Errorf("%s: destructive() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestTestMode(t *testing.T) {
+	var auth, tag string
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		auth, tag = r.Header.Get("Authorization"), r.Header.Get(TEST_HEADER)
+		w.Write([]byte(`{"status":"success"}`))
+	}))
+	defer server.Close()
+
+	test := StaticCredentials(&APIKey{MasterKey: "test"})
+	s := NewClient(WithBaseURL("http://127.0.0.1:1"), WithAPIKey(&APIKey{MasterKey: "prod"}),
+		WithTestMode(TestMode{BaseURL: server.URL, Credentials: test}))
+	if _, err := s.GetDatabase(); err != nil || auth != "test" || tag != "true" {
+		t.Fatalf("GetDatabase() = %v with key %q and header %q, want the test key and header", err, auth, tag)
+	}
+	refused := map[string]func() (map[string]interface{}, error){
+		"Delete":           func() (map[string]interface{}, error) { return s.Delete(map[string]interface{}{}) },
+		"Update":           func() (map[string]interface{}, error) { return s.Update(map[string]interface{}{}) },
+		"DeleteSavedQuery": func() (map[string]interface{}, error) { return s.DeleteSavedQuery("q") },
+		"Sql":              func() (map[string]interface{}, error) { return s.Sql("/* x */ DELETE FROM t") },
+	}
+	for name, call := range refused {
+		if _, err := call(); !errors.Is(err, ErrTestMode) {
+			t.Errorf("%s() error = %v, want ErrTestMode", name, err)
+		}
+	}
+	if requests != 1 {
+		t.Fatalf("server got %d requests, want 1", requests)
+	}
+
+	s = NewClient(WithAPIKey(&APIKey{MasterKey: "prod"}),
+		WithTestMode(TestMode{BaseURL: server.URL, Credentials: test, AllowDestructive: true}))
+	if _, err := s.DeleteSavedQuery("q"); err != nil || auth != "test" {
+		t.Fatalf("DeleteSavedQuery() = %v with key %q, want it allowed with the test key", err, auth)
+	}
+
+	s = NewClient(WithBaseURL(server.URL), WithAPIKey(&APIKey{MasterKey: "prod"}), WithTestMode(TestMode{Credentials: test}))
+	if _, err := s.GetDatabase(); err != nil || auth != "test" || tag != "true" {
+		t.Fatalf("GetDatabase() = %v with key %q and header %q, want the client base URL with the test key", err, auth, tag)
+	}
+
+	unconfigured := []TestMode{{}, {BaseURL: server.URL}}
+	for _, mode := range unconfigured {
+		s = NewClient(WithBaseURL(server.URL), WithAPIKey(&APIKey{MasterKey: "prod"}), WithTestMode(mode))
+		if _, err := s.GetDatabase(); !errors.Is(err, ErrTestMode) {
+			t.Errorf("GetDatabase() with %+v error = %v, want ErrTestMode", mode, err)
+		}
+	}
+	s = NewClient(WithBaseURL(server.URL), WithAPIKey(&APIKey{MasterKey: "prod"}))
+	s.Test = true
+	if _, err := s.GetDatabase(); !errors.Is(err, ErrTestMode) {
+		t.Errorf("GetDatabase() with Test and no TestMode error = %v, want ErrTestMode", err)
+	}
+	if requests != 3 {
+		t.Fatalf("server got %d requests, want 3", requests)
+	}
+
+	s.Test = false
+	if _, err := s.GetDatabase(); err != nil || auth != "prod" || tag != "" {
+		t.Fatalf("GetDatabase() = %v with key %q and header %q, want the production key and no header", err, auth, tag)
+	}
+}
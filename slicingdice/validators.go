@@ -0,0 +1,386 @@
+package slicingdice
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ValidationError is returned when a query is rejected by the client before
+// being sent to the API. It matches ErrInvalidQuery.
+type ValidationError struct {
+	Validator string // validator that rejected the query, e.g. "Count Query Validator"
+	Path      string // JSON path of the offending element, e.g. $.query[2]
+	Message   string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("%s: %s: %s", e.Validator, e.Path, e.Message)
+}
+
+// Is reports whether target is ErrInvalidQuery.
+func (e *ValidationError) Is(target error) bool {
+	return target == ErrInvalidQuery
+}
+
+// identifier matches the keys that can be written in dot notation in a JSON
+// path.
+var identifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// keyPath returns the JSON path of the element key of the object at path.
+func keyPath(path string, key string) string {
+	if identifier.MatchString(key) {
+		return path + "." + key
+	}
+	return path + "[" + strconv.Quote(key) + "]"
+}
+
+// indexPath returns the JSON path of the element i of the array at path.
+func indexPath(path string, i int) string {
+	return path + "[" + strconv.Itoa(i) + "]"
+}
+
+// objectOf returns value as a map with string keys, whatever the Go types of
+// its keys and values.
+func objectOf(value interface{}) (reflect.Value, bool) {
+	v := reflect.ValueOf(value)
+	if v.Kind() == reflect.Map && v.Type().Key().Kind() == reflect.String {
+		return v, !v.IsNil()
+	}
+	return v, false
+}
+
+// arrayOf returns value as a slice or array, whatever the Go type of its
+// elements.
+func arrayOf(value interface{}) (reflect.Value, bool) {
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Slice:
+		return v, !v.IsNil()
+	case reflect.Array:
+		return v, true
+	}
+	return v, false
+}
+
+// field returns the value of the key name of object, if present.
+func field(object reflect.Value, name string) (interface{}, bool) {
+	v := object.MapIndex(reflect.ValueOf(name).Convert(object.Type().Key()))
+	if !v.IsValid() {
+		return nil, false
+	}
+	return v.Interface(), true
+}
+
+// stringField returns the value of the key name of object. It reports an
+// error if the value is present but is not a string.
+func stringField(object reflect.Value, name string, validator string, path string) (string, bool, error) {
+	value, ok := field(object, name)
+	if !ok {
+		return "", false, nil
+	}
+	str, ok := value.(string)
+	if !ok {
+		return "", false, &ValidationError{validator, keyPath(path, name), fmt.Sprintf("the '%s' key should be a string.", name)}
+	}
+	return str, true, nil
+}
+
+// hasValidSavedQuery checks whether the query passed by user is valid. It
+// validates especially if the query type is valid.
+func hasValidSavedQuery(query interface{}) error {
+	const validator = "Saved Query Validator"
+	queryConverted, ok := objectOf(query)
+	if !ok {
+		return &ValidationError{validator, "$", "the saved query should be an object."}
+	}
+	listQueryTypes := []string{"count/entity", "count/event", "count/entity/total", "aggregation", "top_values"}
+	queryType, ok, err := stringField(queryConverted, "type", validator, "$")
+	if err != nil {
+		return err
+	}
+	if !ok || !stringInSlice(queryType, listQueryTypes) {
+		return &ValidationError{validator, "$.type", "this dictionary don't have query type valid."}
+	}
+	return nil
+}
+
+// hasValidCountQuery checks whether the count query passed by user is valid. It
+// validates especially if the query len is less than 10.
+func hasValidCountQuery(query interface{}) error {
+	const validator = "Count Query Validator"
+	if _, ok := objectOf(query); ok {
+		return nil
+	}
+	queries, ok := arrayOf(query)
+	if !ok {
+		return &ValidationError{validator, "$", "the count query should be an object or a list of objects."}
+	}
+	if queries.Len() > 10 {
+		return &ValidationError{validator, "$", "the query count entity has a limit of 10 queries by request."}
+	}
+	for i := 0; i < queries.Len(); i++ {
+		if _, ok := objectOf(queries.Index(i).Interface()); !ok {
+			return &ValidationError{validator, indexPath("$", i), "each count query should be an object."}
+		}
+	}
+	return nil
+}
+
+// hasValidTopValuesQuery checks whether the top values query passed by user is valid. It
+// validates especially if the query len is less than 5 and column len is less than 6.
+func hasValidTopValuesQuery(query interface{}) error {
+	const validator = "Top Values Validator"
+	queryConverted, ok := objectOf(query)
+	if !ok {
+		return &ValidationError{validator, "$", "the top values query should be an object."}
+	}
+	// check query limit
+	if queryConverted.Len() > 5 {
+		return &ValidationError{validator, "$", "the top values query has a limit of 5 queries by request."}
+	}
+	// check column limit
+	iter := queryConverted.MapRange()
+	for iter.Next() {
+		path := keyPath("$", iter.Key().String())
+		value, ok := objectOf(iter.Value().Interface())
+		if !ok {
+			return &ValidationError{validator, path, "each top values query should be an object."}
+		}
+		if value.Len() > 6 {
+			return &ValidationError{validator, path, "the query exceeds the limit of columns per query in request"}
+		}
+	}
+	return nil
+}
+
+// hasValidDataExtractionQuery checks whether the data extraction(result and score)
+// query passed by user is valid. It validates especially if the 'limit' key
+// has a len less than 100 and if has a valid column.
+func hasValidDataExtractionQuery(query interface{}) error {
+	const validator = "Data Extraction Validator"
+	queryConverted, ok := objectOf(query)
+	if !ok {
+		return &ValidationError{validator, "$", "the data extraction query should be an object."}
+	}
+	if val, ok := field(queryConverted, "columns"); ok {
+		if _, ok := val.(string); ok {
+			// e.g. "all"
+			return nil
+		}
+		columns, ok := arrayOf(val)
+		if !ok {
+			return &ValidationError{validator, "$.columns", "The key 'columns' in data extraction result must be a list of columns."}
+		}
+		if columns.Len() > 10 {
+			return &ValidationError{validator, "$.columns", "The key 'columns' in data extraction result must have up to 10 columns."}
+		}
+	}
+	return nil
+}
+
+// Limits checked by the column validator.
+const (
+	maxColumnNameLength        = 80
+	maxColumnDescriptionLength = 300
+	maxDecimalPlace            = 5
+)
+
+// apiName matches the valid api-names of columns and names of dimensions:
+// lowercase letters, digits, hyphens and underscores, starting with a
+// letter.
+var apiNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_-]*$`)
+
+// ValidationErrors is returned when a validator finds several problems, e.g.
+// in a batch of columns. Its Unwrap method returns every error found.
+type ValidationErrors struct {
+	Errors []*ValidationError
+}
+
+func (e *ValidationErrors) Error() string {
+	messages := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		messages[i] = err.Error()
+	}
+	return fmt.Sprintf("%d validation errors: %s", len(e.Errors), strings.Join(messages, "; "))
+}
+
+// Unwrap returns every error found.
+func (e *ValidationErrors) Unwrap() []error {
+	errs := make([]error, len(e.Errors))
+	for i, err := range e.Errors {
+		errs[i] = err
+	}
+	return errs
+}
+
+// Is reports whether target is ErrInvalidQuery.
+func (e *ValidationErrors) Is(target error) bool {
+	return target == ErrInvalidQuery
+}
+
+// hasValidColumn checks whether the new column, or every column of a batch,
+// is valid. It returns a ValidationError naming the column index and
+// api-name, or ValidationErrors if several problems were found.
+func hasValidColumn(query interface{}) error {
+	const validator = "Column Validator"
+	var errs []*ValidationError
+	if columnData, ok := arrayOf(query); ok {
+		for i := 0; i < columnData.Len(); i++ {
+			column, ok := objectOf(columnData.Index(i).Interface())
+			if !ok {
+				errs = append(errs, &ValidationError{validator, indexPath("$", i), fmt.Sprintf("column %d: each column should be an object.", i)})
+				continue
+			}
+			errs = append(errs, validateColumn(column, i, indexPath("$", i))...)
+		}
+	} else if column, ok := objectOf(query); ok {
+		errs = validateColumn(column, 0, "$")
+	} else {
+		return &ValidationError{validator, "$", "the column should be an object or a list of objects."}
+	}
+	switch len(errs) {
+	case 0:
+		return nil
+	case 1:
+		return errs[0]
+	}
+	return &ValidationErrors{errs}
+}
+
+// validateColumn returns every problem found in the column at index of a
+// batch, whose JSON path is path.
+func validateColumn(query reflect.Value, index int, path string) []*ValidationError {
+	const validator = "Column Validator"
+
+	var errs []*ValidationError
+	column := fmt.Sprintf("column %d", index)
+	if value, ok := field(query, "api-name"); ok {
+		if name, ok := value.(string); ok {
+			column = fmt.Sprintf("column %d (%q)", index, name)
+		}
+	}
+	fail := func(key string, message string) {
+		errs = append(errs, &ValidationError{validator, keyPath(path, key), column + ": " + message})
+	}
+	str := func(key string) (string, bool) {
+		value, ok := field(query, key)
+		if !ok {
+			return "", false
+		}
+		s, ok := value.(string)
+		if !ok {
+			fail(key, fmt.Sprintf("the '%s' key should be a string.", key))
+		}
+		return s, ok
+	}
+
+	// validate name
+	if name, ok := str("name"); ok {
+		if len(name) > maxColumnNameLength {
+			fail("name", "the column's name have a very big name.(Max: 80 chars)")
+		}
+	} else if _, present := field(query, "name"); !present {
+		fail("name", "the column should have a name.")
+	}
+	// validate api-name
+	if name, ok := str("api-name"); ok {
+		if len(name) > maxColumnNameLength {
+			fail("api-name", "the column's api-name is too long.(Max: 80 chars)")
+		} else if !apiNamePattern.MatchString(name) {
+			fail("api-name", "the column's api-name should start with a lowercase letter and have only lowercase letters, digits, '-' and '_'.")
+		}
+	}
+	// validate description
+	if description, ok := str("description"); ok && len(description) > maxColumnDescriptionLength {
+		fail("description", "the column's description have a very big name.(Max: 300chars)")
+	}
+	// validate dimension
+	if dimension, ok := str("dimension"); ok && !apiNamePattern.MatchString(dimension) {
+		fail("dimension", "the column's dimension should start with a lowercase letter and have only lowercase letters, digits, '-' and '_'.")
+	}
+	// validate storage
+	if storage, ok := str("storage"); ok && !Storage(storage).Valid() {
+		fail("storage", fmt.Sprintf("the column 'storage' has invalid value, it should be one of %s.", joinValues(Storages)))
+	}
+	// validate type column
+	typeColumn, ok := str("type")
+	if !ok {
+		if _, present := field(query, "type"); !present {
+			fail("type", "the column should have a type.")
+		}
+		return errs
+	}
+	if !ColumnType(typeColumn).Valid() {
+		fail("type", "this column have a invalid type.")
+		return errs
+	}
+	// validate decimal place key
+	if value, ok := field(query, "decimal-place"); ok {
+		if !ColumnType(typeColumn).Decimal() {
+			fail("decimal-place", "this column is only accepted on type 'decimal' or 'decimal-event'.")
+		} else if places, ok := integerOf(value); !ok || places < 0 || places > maxDecimalPlace {
+			fail("decimal-place", fmt.Sprintf("the column 'decimal-place' should be an integer from 0 to %d.", maxDecimalPlace))
+		}
+	}
+	// validate string column type
+	if ColumnType(typeColumn) == TYPE_STRING {
+		if cardinality, ok := str("cardinality"); ok {
+			if !Cardinality(cardinality).Valid() {
+				fail("cardinality", "the column 'cardinality' has invalid value.")
+			}
+		} else if _, present := field(query, "cardinality"); !present {
+			fail("cardinality", "the column with type string should have 'cardinality' key.")
+		}
+	}
+	// validate enumerated column
+	if ColumnType(typeColumn) == TYPE_ENUMERATED {
+		value, ok := field(query, "range")
+		if !ok {
+			fail("range", "the 'enumerate' type needs of the 'range' parameter.")
+		} else if values, ok := arrayOf(value); !ok || values.Len() == 0 {
+			fail("range", "the 'range' parameter should be a non-empty list of values.")
+		} else {
+			seen := map[string]bool{}
+			for i := 0; i < values.Len(); i++ {
+				item, ok := values.Index(i).Interface().(string)
+				if !ok {
+					errs = append(errs, &ValidationError{validator, indexPath(keyPath(path, "range"), i), column + ": each value of 'range' should be a string."})
+				} else if seen[item] {
+					errs = append(errs, &ValidationError{validator, indexPath(keyPath(path, "range"), i), column + fmt.Sprintf(": the value %q is repeated in 'range'.", item)})
+				}
+				seen[item] = true
+			}
+		}
+	}
+	return errs
+}
+
+// joinValues returns values separated by commas.
+func joinValues[T ~string](values []T) string {
+	names := make([]string, len(values))
+	for i, value := range values {
+		names[i] = string(value)
+	}
+	return strings.Join(names, ", ")
+}
+
+// integerOf returns value as an int if it is a whole number of any Go
+// numeric type.
+func integerOf(value interface{}) (int, bool) {
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return int(v.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return int(v.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		f := v.Float()
+		if f == float64(int(f)) {
+			return int(f), true
+		}
+	}
+	return 0, false
+}
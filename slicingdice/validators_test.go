@@ -0,0 +1,87 @@
+package slicingdice
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// validationPaths returns the paths of the validation errors in err.
+func validationPaths(err error) []string {
+	var paths []string
+	var many *ValidationErrors
+	var one *ValidationError
+	switch {
+	case errors.As(err, &many):
+		for _, e := range many.Errors {
+			paths = append(paths, e.Path)
+		}
+	case errors.As(err, &one):
+		paths = append(paths, one.Path)
+	}
+	return paths
+}
+
+func TestValidationErrorPath(t *testing.T) {
+	type object = map[string]interface{}
+	tooMany := make([]interface{}, 11)
+	for i := range tooMany {
+		tooMany[i] = object{}
+	}
+	tests := []struct {
+		name     string
+		validate func(interface{}) error
+		query    interface{}
+		paths    []string
+	}{
+		{"saved nil", hasValidSavedQuery, nil, []string{"$"}},
+		{"saved scalar", hasValidSavedQuery, "count/entity", []string{"$"}},
+		{"saved non-string type", hasValidSavedQuery, object{"type": 1}, []string{"$.type"}},
+		{"saved unknown type", hasValidSavedQuery, object{"type": "other"}, []string{"$.type"}},
+		{"saved valid", hasValidSavedQuery, object{"type": "count/entity"}, nil},
+
+		{"count nil", hasValidCountQuery, nil, []string{"$"}},
+		{"count scalar", hasValidCountQuery, 3, []string{"$"}},
+		{"count nil entry", hasValidCountQuery, []interface{}{nil}, []string{"$[0]"}},
+		{"count scalar entry", hasValidCountQuery, []interface{}{object{}, "q"}, []string{"$[1]"}},
+		{"count too many", hasValidCountQuery, tooMany, []string{"$"}},
+		{"count valid", hasValidCountQuery, []object{{"query-name": "q"}}, nil},
+
+		{"top values nil", hasValidTopValuesQuery, nil, []string{"$"}},
+		{"top values scalar", hasValidTopValuesQuery, []interface{}{}, []string{"$"}},
+		{"top values nil entry", hasValidTopValuesQuery, object{"q": nil}, []string{"$.q"}},
+		{"top values quoted name", hasValidTopValuesQuery, object{"top-q": 1}, []string{`$["top-q"]`}},
+		{"top values valid", hasValidTopValuesQuery, object{"q": object{"c": 5}}, nil},
+
+		{"extraction nil", hasValidDataExtractionQuery, nil, []string{"$"}},
+		{"extraction scalar", hasValidDataExtractionQuery, 1, []string{"$"}},
+		{"extraction non-list columns", hasValidDataExtractionQuery, object{"columns": 3}, []string{"$.columns"}},
+		{"extraction object columns", hasValidDataExtractionQuery, object{"columns": object{}}, []string{"$.columns"}},
+		{"extraction too many columns", hasValidDataExtractionQuery, object{"columns": tooMany}, []string{"$.columns"}},
+		{"extraction all columns", hasValidDataExtractionQuery, object{"columns": "all"}, nil},
+
+		{"column nil", hasValidColumn, nil, []string{"$"}},
+		{"column scalar", hasValidColumn, "column", []string{"$"}},
+		{"column nil entry", hasValidColumn, []interface{}{nil}, []string{"$[0]"}},
+		{"column non-string name", hasValidColumn, object{"name": 1, "type": "integer"}, []string{"$.name"}},
+		{"column non-string type", hasValidColumn, object{"name": "n", "type": 2}, []string{"$.type"}},
+		{"column decimal place", hasValidColumn, object{"name": "n", "type": "integer", "decimal-place": 2}, []string{`$["decimal-place"]`}},
+		{"column non-list range", hasValidColumn, object{"name": "n", "type": "enumerated", "range": "a"}, []string{"$.range"}},
+		{"column bad range entries", hasValidColumn, object{"name": "n", "type": "enumerated", "range": []interface{}{"a", 1, "a"}}, []string{"$.range[1]", "$.range[2]"}},
+		{"column batch", hasValidColumn, []interface{}{
+			object{"name": "n", "type": "integer"},
+			object{"type": "integer"},
+			object{"name": "n", "type": "enumerated", "range": []interface{}{nil}},
+		}, []string{"$[1].name", "$[2].range[0]"}},
+		{"column valid", hasValidColumn, object{"name": "n", "type": "string", "cardinality": "high"}, nil},
+	}
+	for _, tt := range tests {
+		err := tt.validate(tt.query)
+		if got := validationPaths(err); !reflect.DeepEqual(got, tt.paths) {
+			t.Errorf("%s: got paths %q from %v, want %q", tt.name, got, err, tt.paths)
+		}
+		if tt.paths != nil && !errors.Is(err, ErrInvalidQuery) {
+			t.Errorf("%s: error %v does not match ErrInvalidQuery", tt.name, err)
+		}
+	}
+}